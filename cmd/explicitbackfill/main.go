@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/foreground-eclipse/song-library/internal/config"
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"go.uber.org/zap"
+)
+
+// explicitbackfill classifies the lyrics of all the songs as explicit or
+// not, it is run after the explicit words list changes
+func main() {
+	batch := flag.Int("batch", 500, "the number of songs classified in a transaction")
+	flag.Parse()
+
+	cfg := config.MustLoad()
+
+	log, err := logger.NewLogger("INFO")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	storage, err := postgres.New(cfg)
+	if err != nil {
+		log.LogError("failed to create database connection", zap.Error(err))
+		os.Exit(1)
+	}
+
+	var after, total int
+	for {
+		last, n, err := storage.BackfillExplicit(after, *batch)
+		if err != nil {
+			log.LogError("failed to classify songs", zap.Int("after", after), zap.Error(err))
+			os.Exit(1)
+		}
+		if n == 0 {
+			break
+		}
+
+		after = last
+		total += n
+		log.LogInfo("classified songs", zap.Int("batch", n), zap.Int("total", total))
+	}
+
+	log.LogInfo("backfill finished", zap.Int("total", total))
+}
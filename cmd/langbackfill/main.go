@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/foreground-eclipse/song-library/internal/config"
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"go.uber.org/zap"
+)
+
+// langbackfill detects the language of the lyrics of the songs stored
+// before the detection was introduced
+func main() {
+	batch := flag.Int("batch", 500, "the number of songs tagged in a transaction")
+	redetect := flag.Bool("redetect", false, "detect the language of the songs already tagged too")
+	flag.Parse()
+
+	cfg := config.MustLoad()
+
+	log, err := logger.NewLogger("INFO")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	storage, err := postgres.New(cfg)
+	if err != nil {
+		log.LogError("failed to create database connection", zap.Error(err))
+		os.Exit(1)
+	}
+
+	var after, total int
+	for {
+		last, n, err := storage.BackfillLanguages(after, *batch, *redetect)
+		if err != nil {
+			log.LogError("failed to backfill languages", zap.Int("after", after), zap.Error(err))
+			os.Exit(1)
+		}
+		if n == 0 {
+			break
+		}
+
+		after = last
+		total += n
+		log.LogInfo("tagged songs", zap.Int("batch", n), zap.Int("total", total))
+	}
+
+	log.LogInfo("backfill finished", zap.Int("total", total))
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -9,14 +10,28 @@ import (
 	docs "github.com/foreground-eclipse/song-library/cmd/songlibrary/docs"
 
 	"github.com/foreground-eclipse/song-library/internal/config"
+	"github.com/foreground-eclipse/song-library/internal/enrichment"
 	addsong "github.com/foreground-eclipse/song-library/internal/handlers/add"
+	"github.com/foreground-eclipse/song-library/internal/handlers/annotation"
+	"github.com/foreground-eclipse/song-library/internal/handlers/concordance"
 	"github.com/foreground-eclipse/song-library/internal/handlers/couplet"
+	"github.com/foreground-eclipse/song-library/internal/handlers/revision"
+	"github.com/foreground-eclipse/song-library/internal/handlers/synced"
+	"github.com/foreground-eclipse/song-library/internal/handlers/trash"
+	"github.com/foreground-eclipse/song-library/internal/handlers/variant"
 
 	songdelete "github.com/foreground-eclipse/song-library/internal/handlers/delete"
+	songenrichment "github.com/foreground-eclipse/song-library/internal/handlers/enrichment"
 	songget "github.com/foreground-eclipse/song-library/internal/handlers/get"
+	songlist "github.com/foreground-eclipse/song-library/internal/handlers/list"
+	songlyrics "github.com/foreground-eclipse/song-library/internal/handlers/lyrics"
+	songrhymes "github.com/foreground-eclipse/song-library/internal/handlers/rhymes"
+	songsearch "github.com/foreground-eclipse/song-library/internal/handlers/search"
+	songstats "github.com/foreground-eclipse/song-library/internal/handlers/stats"
 	"github.com/foreground-eclipse/song-library/internal/handlers/update"
 
 	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/purge"
 	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
 	"github.com/gin-gonic/gin"
 
@@ -39,7 +54,6 @@ func init() {
 func main() {
 	cfg := config.MustLoad()
 	docs.SwaggerInfo.BasePath = "/api/v1"
-	fmt.Println(cfg)
 
 	logLevel := "DEBUG"
 	log, err := logger.NewLogger(logLevel)
@@ -66,6 +80,35 @@ func main() {
 		log.LogError("failed to apply migrations", zap.Error(err))
 	}
 
+	enricher, err := enrichment.New(log, cfg.Enrichment)
+	if err != nil {
+		log.LogError("failed to create enrichment client", zap.Error(err))
+		os.Exit(1)
+	}
+
+	providers, err := enrichment.NewProviders(cfg.Enrichment, enricher)
+	if err != nil {
+		log.LogError("failed to create enrichment providers", zap.Error(err))
+		os.Exit(1)
+	}
+
+	chain, err := enrichment.NewChain(log, providers, cfg.Enrichment.FieldRules)
+	if err != nil {
+		log.LogError("failed to create enrichment chain", zap.Error(err))
+		os.Exit(1)
+	}
+
+	var cacheStore enrichment.CacheStore
+	if cfg.Enrichment.CachePersistent {
+		cacheStore = storage
+	}
+	cache := enrichment.NewCache(log, chain, cacheStore, cfg.Enrichment)
+
+	go purge.Run(context.Background(), log, storage, cfg.Trash.Retention, cfg.Trash.PurgeInterval)
+	go cache.Run(context.Background(), cfg.Enrichment.CachePurgeInterval)
+	go enrichment.NewWorker(log, storage, cache, cfg.Enrichment).
+		Run(context.Background(), cfg.Enrichment.Workers, cfg.Enrichment.JobTimeout)
+
 	router := gin.Default()
 
 	router.GET("/ping", func(ctx *gin.Context) {
@@ -75,11 +118,46 @@ func main() {
 		})
 	})
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
-	router.POST("/api/v1/song/add", addsong.New(log, storage))
+	router.POST("/api/v1/song/add", addsong.New(log, storage, cache))
 	router.GET("/api/v1/song/get", songget.New(log, storage))
+	router.GET("/api/v1/song/list", songlist.New(log, storage))
+	router.GET("/api/v1/song/search", songsearch.New(log, storage))
+	router.GET("/api/v1/concordance", concordance.New(log, storage))
 	router.GET("/api/v1/song/couplet", couplet.New(log, storage))
 	router.DELETE("/api/v1/song/delete", songdelete.New(log, storage))
 	router.POST("/api/v1/song/update", update.New(log, storage))
+	router.PATCH("/api/v1/song/update", update.NewPatchByName(log, storage))
+
+	router.GET("/api/v1/songs/:id", songget.NewByID(log, storage))
+	router.PUT("/api/v1/songs/:id", update.NewByID(log, storage))
+	router.PATCH("/api/v1/songs/:id", update.NewPatch(log, storage))
+	router.DELETE("/api/v1/songs/:id", songdelete.NewByID(log, storage))
+	router.GET("/api/v1/songs/:id/lyrics", songlyrics.New(log, storage))
+	router.GET("/api/v1/songs/:id/rhymes", songrhymes.New(log, storage))
+	router.GET("/api/v1/songs/:id/enrichment", songenrichment.New(log, storage))
+	router.GET("/api/v1/songs/:id/annotations", annotation.NewList(log, storage))
+	router.POST("/api/v1/songs/:id/annotations", annotation.NewAdd(log, storage))
+	router.PATCH("/api/v1/songs/:id/annotations/:annotation_id", annotation.NewUpdate(log, storage))
+	router.DELETE("/api/v1/songs/:id/annotations/:annotation_id", annotation.NewDelete(log, storage))
+	router.GET("/api/v1/songs/:id/synced", synced.NewExport(log, storage))
+	router.PUT("/api/v1/songs/:id/synced", synced.NewImport(log, storage))
+	router.DELETE("/api/v1/songs/:id/synced", synced.NewDelete(log, storage))
+	router.GET("/api/v1/songs/:id/synced/at", synced.NewAt(log, storage))
+	router.GET("/api/v1/songs/:id/variants", variant.NewList(log, storage))
+	router.GET("/api/v1/songs/:id/variants/align", variant.NewAlign(log, storage))
+	router.PUT("/api/v1/songs/:id/variants/:lang", variant.NewSave(log, storage))
+	router.DELETE("/api/v1/songs/:id/variants/:lang", variant.NewDelete(log, storage))
+	router.GET("/api/v1/songs/:id/stats", songstats.NewSong(log, storage))
+	router.GET("/api/v1/songs/:id/revisions", revision.NewList(log, storage))
+	router.GET("/api/v1/songs/:id/revisions/diff", revision.NewDiff(log, storage))
+	router.POST("/api/v1/songs/:id/revisions/:version/restore", revision.NewRestore(log, storage))
+
+	router.GET("/api/v1/groups/:group/stats", songstats.NewGroup(log, storage))
+
+	router.GET("/api/v1/enrichment/cache/stats", songenrichment.NewCacheStats(log, cache))
+
+	router.GET("/api/v1/trash", trash.NewList(log, storage))
+	router.POST("/api/v1/trash/:id/restore", trash.NewRestore(log, storage))
 
 	if err := router.Run(":8080"); err != nil {
 		log.LogError("Failed to start server", zap.Error(err))
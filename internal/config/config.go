@@ -13,6 +13,9 @@ type Config struct {
 	Env string `env:"ENV"`
 	HTTPServer
 	DBData
+	Trash
+	Explicit
+	Enrichment
 }
 
 // DBData is a struct that represents DB data in config
@@ -32,6 +35,58 @@ type HTTPServer struct {
 	IdleTimeout time.Duration `env:"HTTP_SERVER_IDLE_TIMEOUT"`
 }
 
+// Trash is a struct that represents deleted songs retention in config
+type Trash struct {
+	Retention     time.Duration `env:"TRASH_RETENTION" env-default:"720h"`
+	PurgeInterval time.Duration `env:"TRASH_PURGE_INTERVAL" env-default:"1h"`
+}
+
+// Explicit is a struct that represents explicit lyrics detection in config,
+// the built-in word list is used without a words file
+type Explicit struct {
+	WordsFile string `env:"EXPLICIT_WORDS_FILE"`
+}
+
+// Enrichment is a struct that represents the music info API client in config
+type Enrichment struct {
+	BaseURL               string        `env:"ENRICHMENT_BASE_URL" env-default:"http://localhost:8081"`
+	Timeout               time.Duration `env:"ENRICHMENT_TIMEOUT" env-default:"5s"`
+	AuthHeader            string        `env:"ENRICHMENT_AUTH_HEADER" env-default:"Authorization"`
+	AuthToken             string        `env:"ENRICHMENT_AUTH_TOKEN"`
+	TLSCAFile             string        `env:"ENRICHMENT_TLS_CA_FILE"`
+	TLSCertFile           string        `env:"ENRICHMENT_TLS_CERT_FILE"`
+	TLSKeyFile            string        `env:"ENRICHMENT_TLS_KEY_FILE"`
+	TLSInsecureSkipVerify bool          `env:"ENRICHMENT_TLS_INSECURE_SKIP_VERIFY"`
+
+	RetryAttempts    int           `env:"ENRICHMENT_RETRY_ATTEMPTS" env-default:"3"`
+	RetryBaseDelay   time.Duration `env:"ENRICHMENT_RETRY_BASE_DELAY" env-default:"100ms"`
+	RetryMaxDelay    time.Duration `env:"ENRICHMENT_RETRY_MAX_DELAY" env-default:"2s"`
+	BreakerThreshold int           `env:"ENRICHMENT_BREAKER_THRESHOLD" env-default:"5"`
+	BreakerCooldown  time.Duration `env:"ENRICHMENT_BREAKER_COOLDOWN" env-default:"30s"`
+
+	Workers          int           `env:"ENRICHMENT_WORKERS" env-default:"4"`
+	PollInterval     time.Duration `env:"ENRICHMENT_POLL_INTERVAL" env-default:"1s"`
+	JobAttempts      int           `env:"ENRICHMENT_JOB_ATTEMPTS" env-default:"5"`
+	JobRetryDelay    time.Duration `env:"ENRICHMENT_JOB_RETRY_DELAY" env-default:"30s"`
+	JobRetryMaxDelay time.Duration `env:"ENRICHMENT_JOB_RETRY_MAX_DELAY" env-default:"30m"`
+	JobTimeout       time.Duration `env:"ENRICHMENT_JOB_TIMEOUT" env-default:"5m"`
+
+	CacheTTL           time.Duration `env:"ENRICHMENT_CACHE_TTL" env-default:"24h"`
+	CacheNegativeTTL   time.Duration `env:"ENRICHMENT_CACHE_NEGATIVE_TTL" env-default:"1h"`
+	CacheSize          int           `env:"ENRICHMENT_CACHE_SIZE" env-default:"10000"`
+	CachePersistent    bool          `env:"ENRICHMENT_CACHE_PERSISTENT" env-default:"true"`
+	CachePurgeInterval time.Duration `env:"ENRICHMENT_CACHE_PURGE_INTERVAL" env-default:"1h"`
+
+	// Providers are tried in this order, FieldRules override the order for
+	// single attributes as in "release_date=file,api;link=api,file"
+	Providers         []string `env:"ENRICHMENT_PROVIDERS" env-separator:"," env-default:"api"`
+	FieldRules        string   `env:"ENRICHMENT_FIELD_RULES"`
+	MetadataFile      string   `env:"ENRICHMENT_METADATA_FILE"`
+	StaticReleaseDate string   `env:"ENRICHMENT_STATIC_RELEASE_DATE"`
+	StaticText        string   `env:"ENRICHMENT_STATIC_TEXT"`
+	StaticLink        string   `env:"ENRICHMENT_STATIC_LINK"`
+}
+
 // MustLoad loads the config
 func MustLoad() *Config {
 	configPath, err := filepath.Abs("./config/config.env")
@@ -0,0 +1,70 @@
+package enrichment
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	const cooldown = time.Minute
+
+	tests := []struct {
+		name  string
+		steps string // f - failure, s - success, r - release, a - allow, w - wait the cooldown
+		allow error
+		state breakerState
+	}{
+		{name: "closed below the threshold", steps: "ff", allow: nil, state: breakerClosed},
+		{name: "opens at the threshold", steps: "fff", allow: ErrCircuitOpen, state: breakerOpen},
+		{name: "success resets the failures", steps: "ffsff", allow: nil, state: breakerClosed},
+		{name: "half-open after the cooldown", steps: "fffw", allow: nil, state: breakerHalfOpen},
+		{name: "single trial call", steps: "fffwa", allow: ErrCircuitOpen, state: breakerHalfOpen},
+		{name: "successful trial closes", steps: "fffwas", allow: nil, state: breakerClosed},
+		{name: "failed trial opens again", steps: "fffwaf", allow: ErrCircuitOpen, state: breakerOpen},
+		{name: "released trial lets a new trial through", steps: "fffwar", allow: nil, state: breakerHalfOpen},
+		{name: "reopened circuit waits a new cooldown", steps: "fffwafw", allow: nil, state: breakerHalfOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			b := NewBreaker(3, cooldown)
+			b.now = func() time.Time { return now }
+
+			for _, step := range tt.steps {
+				switch step {
+				case 'f':
+					b.Failure()
+				case 's':
+					b.Success()
+				case 'r':
+					b.Release()
+				case 'a':
+					if err := b.Allow(); err != nil {
+						t.Fatalf("Allow() = %v before the last step", err)
+					}
+				case 'w':
+					now = now.Add(cooldown)
+				}
+			}
+
+			if err := b.Allow(); !errors.Is(err, tt.allow) {
+				t.Errorf("Allow() = %v, want %v", err, tt.allow)
+			}
+			if b.state != tt.state {
+				t.Errorf("state = %v, want %v", b.state, tt.state)
+			}
+		})
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	b := NewBreaker(0, time.Minute)
+	for i := 0; i < 10; i++ {
+		b.Failure()
+	}
+	if err := b.Allow(); err != nil {
+		t.Errorf("Allow() of a disabled breaker = %v, want nil", err)
+	}
+}
@@ -0,0 +1,286 @@
+package enrichment
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/foreground-eclipse/song-library/internal/config"
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"go.uber.org/zap"
+)
+
+type CacheStore interface {
+	GetCachedInfo(group, song string) (postgres.CachedInfo, error)
+	SaveCachedInfo(group, song string, info postgres.CachedInfo) error
+	PurgeCachedInfo() (int64, error)
+}
+
+// CacheStats are the counters of the cache since the start
+type CacheStats struct {
+	Entries  int `json:"entries"`
+	Capacity int `json:"capacity"`
+	// Hits are the lookups answered from memory, PersistentHits the ones
+	// answered from the persistent tier and NegativeHits the hits of either
+	// tier for songs unknown to the API
+	Hits           uint64 `json:"hits"`
+	PersistentHits uint64 `json:"persistent_hits"`
+	NegativeHits   uint64 `json:"negative_hits"`
+	Misses         uint64 `json:"misses"`
+	// Shared are the misses answered by a concurrent lookup of the same song
+	Shared    uint64 `json:"shared"`
+	Evictions uint64 `json:"evictions"`
+	// HitRatio is the share of lookups answered by either tier
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+// Cache caches the responses of the music info API in memory and in the
+// persistent store. Song info is kept for the TTL and unknown songs for the
+// negative TTL, failures and degraded info are not cached. The least recently used entries are
+// evicted from memory beyond its size. Concurrent misses of the same song
+// share a single lookup.
+type Cache struct {
+	log         *logger.Logger
+	getter      InfoGetter
+	store       CacheStore
+	ttl         time.Duration
+	negativeTTL time.Duration
+	size        int
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	flight  flight
+	now     func() time.Time
+
+	hits           atomic.Uint64
+	persistentHits atomic.Uint64
+	negativeHits   atomic.Uint64
+	misses         atomic.Uint64
+	shared         atomic.Uint64
+	evictions      atomic.Uint64
+}
+
+type cacheEntry struct {
+	key  string
+	info postgres.CachedInfo
+}
+
+// NewCache returns the cache of the responses of the getter, a nil store
+// keeps them in memory only
+func NewCache(log *logger.Logger, getter InfoGetter, store CacheStore, cfg config.Enrichment) *Cache {
+	return &Cache{
+		log:         log,
+		getter:      getter,
+		store:       store,
+		ttl:         cfg.CacheTTL,
+		negativeTTL: cfg.CacheNegativeTTL,
+		size:        max(cfg.CacheSize, 0),
+		lru:         list.New(),
+		entries:     make(map[string]*list.Element),
+		now:         time.Now,
+	}
+}
+
+// GetInfo gets full information about the song from the cache, looking it
+// up with the getter on a miss
+func (c *Cache) GetInfo(ctx context.Context, group, song string) (SongDetail, error) {
+	const op = "enrichment.Cache.GetInfo"
+
+	groupKey, songKey := cacheKey(group), cacheKey(song)
+	key := groupKey + "\x00" + songKey
+
+	if info, ok := c.get(key); ok {
+		c.hits.Add(1)
+		return c.result(op, info)
+	}
+
+	detail, err, shared := c.flight.do(key, func() (SongDetail, error) {
+		return c.lookup(ctx, key, group, song)
+	})
+	if shared {
+		c.shared.Add(1)
+	}
+	return detail, err
+}
+
+// lookup gets the info of the song missing in memory from the persistent
+// store or the getter, caching the response
+func (c *Cache) lookup(ctx context.Context, key, group, song string) (SongDetail, error) {
+	const op = "enrichment.Cache.GetInfo"
+
+	groupKey, songKey := cacheKey(group), cacheKey(song)
+
+	if c.store != nil {
+		info, err := c.store.GetCachedInfo(groupKey, songKey)
+		switch {
+		case err == nil:
+			c.persistentHits.Add(1)
+			c.put(key, info)
+			return c.result(op, info)
+		case !errors.Is(err, postgres.ErrCacheMiss):
+			c.log.LogError("failed to read the persistent cache", zap.String("op", op),
+				zap.Error(err))
+		}
+	}
+
+	c.misses.Add(1)
+	detail, err := c.getter.GetInfo(ctx, group, song)
+
+	var info postgres.CachedInfo
+	switch {
+	case err == nil && detail.Degraded:
+		// the info is incomplete until the failed providers recover
+		return detail, nil
+	case err == nil:
+		info = postgres.CachedInfo{
+			Found:       true,
+			ReleaseDate: detail.ReleaseDate,
+			Text:        detail.Text,
+			Link:        detail.Link,
+			Sources:     detail.Sources,
+			ExpiresAt:   c.now().Add(c.ttl),
+		}
+	case errors.Is(err, ErrNotFound):
+		info = postgres.CachedInfo{ExpiresAt: c.now().Add(c.negativeTTL)}
+	default:
+		return SongDetail{}, err
+	}
+	if !info.ExpiresAt.After(c.now()) {
+		// caching is disabled by a zero TTL
+		return detail, err
+	}
+
+	c.put(key, info)
+	if c.store != nil {
+		if err := c.store.SaveCachedInfo(groupKey, songKey, info); err != nil {
+			c.log.LogError("failed to write the persistent cache", zap.String("op", op),
+				zap.Error(err))
+		}
+	}
+
+	return detail, err
+}
+
+// Stats returns the counters of the cache
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	entries := c.lru.Len()
+	c.mu.Unlock()
+
+	stats := CacheStats{
+		Entries:        entries,
+		Capacity:       c.size,
+		Hits:           c.hits.Load(),
+		PersistentHits: c.persistentHits.Load(),
+		NegativeHits:   c.negativeHits.Load(),
+		Misses:         c.misses.Load(),
+		Shared:         c.shared.Load(),
+		Evictions:      c.evictions.Load(),
+	}
+	if total := stats.Hits + stats.PersistentHits + stats.Misses; total > 0 {
+		ratio := float64(stats.Hits+stats.PersistentHits) / float64(total)
+		stats.HitRatio = math.Round(ratio*1000) / 1000
+	}
+	return stats
+}
+
+// Run purges the expired entries of the persistent store every interval
+// until the context is done
+func (c *Cache) Run(ctx context.Context, interval time.Duration) {
+	const op = "enrichment.Cache.Run"
+
+	if c.store == nil || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := c.store.PurgeCachedInfo()
+		if err != nil {
+			c.log.LogError("failed to purge the persistent cache", zap.String("op", op),
+				zap.Error(err))
+		} else if n > 0 {
+			c.log.LogInfo("purged the persistent cache", zap.String("op", op),
+				zap.Int64("entries", n))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// result returns the cached info as the getter did
+func (c *Cache) result(op string, info postgres.CachedInfo) (SongDetail, error) {
+	if !info.Found {
+		c.negativeHits.Add(1)
+		return SongDetail{}, fmt.Errorf("%s: %w", op, ErrNotFound)
+	}
+	return SongDetail{
+		ReleaseDate: info.ReleaseDate,
+		Text:        info.Text,
+		Link:        info.Link,
+		Sources:     info.Sources,
+	}, nil
+}
+
+// get returns the unexpired entry with given key and marks it recently used
+func (c *Cache) get(key string) (postgres.CachedInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return postgres.CachedInfo{}, false
+	}
+	entry := el.Value.(*cacheEntry)
+	if !entry.info.ExpiresAt.After(c.now()) {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+		return postgres.CachedInfo{}, false
+	}
+	c.lru.MoveToFront(el)
+	return entry.info, true
+}
+
+// put keeps the entry in memory, evicting the least recently used entries
+// beyond the size
+func (c *Cache) put(key string, info postgres.CachedInfo) {
+	if c.size == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		el.Value = &cacheEntry{key: key, info: info}
+		c.lru.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, info: info})
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+		c.evictions.Add(1)
+	}
+}
+
+// cacheKey normalizes a name the way the same song is looked up
+func cacheKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
@@ -0,0 +1,302 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/foreground-eclipse/song-library/internal/config"
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"go.uber.org/zap"
+)
+
+// countingGetter answers every song the same way and counts the calls
+type countingGetter struct {
+	info  SongDetail
+	err   error
+	calls atomic.Int64
+	// release, when set, holds the calls until it is closed
+	release chan struct{}
+	started chan struct{}
+}
+
+func (g *countingGetter) GetInfo(context.Context, string, string) (SongDetail, error) {
+	g.calls.Add(1)
+	if g.release != nil {
+		g.started <- struct{}{}
+		<-g.release
+	}
+	return g.info, g.err
+}
+
+// mapStore is a persistent cache tier in memory
+type mapStore struct {
+	mu      sync.Mutex
+	entries map[string]postgres.CachedInfo
+	err     error
+}
+
+func (s *mapStore) GetCachedInfo(group, song string) (postgres.CachedInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return postgres.CachedInfo{}, s.err
+	}
+	info, ok := s.entries[group+"/"+song]
+	if !ok {
+		return postgres.CachedInfo{}, postgres.ErrCacheMiss
+	}
+	return info, nil
+}
+
+func (s *mapStore) SaveCachedInfo(group, song string, info postgres.CachedInfo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.entries == nil {
+		s.entries = make(map[string]postgres.CachedInfo)
+	}
+	s.entries[group+"/"+song] = info
+	return nil
+}
+
+func (s *mapStore) PurgeCachedInfo() (int64, error) { return 0, nil }
+
+// newTestCache returns a cache with a stopped clock, which the returned time
+// moves
+func newTestCache(getter InfoGetter, store CacheStore, ttl, negativeTTL time.Duration, size int) (*Cache, *time.Time) {
+	cfg := config.Enrichment{CacheTTL: ttl, CacheNegativeTTL: negativeTTL, CacheSize: size}
+	cache := NewCache(&logger.Logger{Logger: zap.NewNop()}, getter, store, cfg)
+
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	return cache, &now
+}
+
+func TestCache(t *testing.T) {
+	errDown := errors.New("connection refused")
+	found := SongDetail{Text: "lyrics"}
+
+	tests := []struct {
+		name string
+		// steps: l - lookup "Muse"/"Uprising", w - wait a minute
+		steps       string
+		info        SongDetail
+		err         error
+		ttl         time.Duration
+		negativeTTL time.Duration
+		calls       int64
+		stats       CacheStats
+	}{
+		{
+			name: "hit after a miss", steps: "ll", info: found, ttl: time.Hour,
+			calls: 1, stats: CacheStats{Entries: 1, Hits: 1, Misses: 1, HitRatio: 0.5},
+		},
+		{
+			name: "unknown songs are cached", steps: "ll", err: ErrNotFound, ttl: time.Hour, negativeTTL: time.Hour,
+			calls: 1, stats: CacheStats{Entries: 1, Hits: 1, NegativeHits: 1, Misses: 1, HitRatio: 0.5},
+		},
+		{
+			name: "failures are not cached", steps: "ll", err: errDown, ttl: time.Hour, negativeTTL: time.Hour,
+			calls: 2, stats: CacheStats{Misses: 2},
+		},
+		{
+			name: "expired info", steps: "lwl", info: found, ttl: 30 * time.Second,
+			calls: 2, stats: CacheStats{Entries: 1, Misses: 2},
+		},
+		{
+			name: "unexpired info", steps: "lwl", info: found, ttl: 2 * time.Minute,
+			calls: 1, stats: CacheStats{Entries: 1, Hits: 1, Misses: 1, HitRatio: 0.5},
+		},
+		{
+			name: "expired unknown song", steps: "lwl", err: ErrNotFound, ttl: time.Hour, negativeTTL: 30 * time.Second,
+			calls: 2, stats: CacheStats{Entries: 1, Misses: 2},
+		},
+		{
+			name: "zero ttl disables caching", steps: "ll", info: found,
+			calls: 2, stats: CacheStats{Misses: 2},
+		},
+		{
+			name: "degraded info is not cached", steps: "ll", info: SongDetail{Text: "lyrics", Degraded: true}, ttl: time.Hour,
+			calls: 2, stats: CacheStats{Misses: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := &countingGetter{info: tt.info, err: tt.err}
+			cache, now := newTestCache(getter, nil, tt.ttl, tt.negativeTTL, 10)
+
+			for _, step := range tt.steps {
+				switch step {
+				case 'l':
+					_, err := cache.GetInfo(context.Background(), "Muse", "Uprising")
+					if !errors.Is(err, tt.err) {
+						t.Fatalf("GetInfo() error = %v, want %v", err, tt.err)
+					}
+				case 'w':
+					*now = now.Add(time.Minute)
+				}
+			}
+
+			if got := getter.calls.Load(); got != tt.calls {
+				t.Errorf("getter calls = %d, want %d", got, tt.calls)
+			}
+			tt.stats.Capacity = 10
+			if got := cache.Stats(); got != tt.stats {
+				t.Errorf("Stats() = %+v, want %+v", got, tt.stats)
+			}
+		})
+	}
+}
+
+func TestCacheKeys(t *testing.T) {
+	getter := &countingGetter{info: SongDetail{Text: "lyrics"}}
+	cache, _ := newTestCache(getter, nil, time.Hour, time.Hour, 10)
+
+	for _, name := range []string{"Uprising", "  uprising ", "UPRISING"} {
+		if _, err := cache.GetInfo(context.Background(), " muse", name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := getter.calls.Load(); got != 1 {
+		t.Errorf("getter calls = %d, want 1", got)
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	getter := &countingGetter{info: SongDetail{Text: "lyrics"}}
+	cache, _ := newTestCache(getter, nil, time.Hour, time.Hour, 2)
+
+	// b is the least recently used song when c is added
+	for _, song := range []string{"a", "b", "a", "c", "a", "b"} {
+		if _, err := cache.GetInfo(context.Background(), "Muse", song); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := CacheStats{Entries: 2, Capacity: 2, Hits: 2, Misses: 4, Evictions: 2, HitRatio: 0.333}
+	if got := cache.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCachePersistentTier(t *testing.T) {
+	errStore := errors.New("database is down")
+	found := SongDetail{Text: "lyrics", Sources: postgres.Sources{"text": "api"}}
+
+	tests := []struct {
+		name     string
+		stored   bool
+		storeErr error
+		calls    int64
+		stats    CacheStats
+	}{
+		{
+			name: "persistent hit", stored: true,
+			calls: 0, stats: CacheStats{Entries: 1, Hits: 1, PersistentHits: 1, HitRatio: 1},
+		},
+		{
+			name:  "persistent miss",
+			calls: 1, stats: CacheStats{Entries: 1, Hits: 1, Misses: 1, HitRatio: 0.5},
+		},
+		{
+			name: "failing store falls back to the getter", storeErr: errStore,
+			calls: 1, stats: CacheStats{Entries: 1, Hits: 1, Misses: 1, HitRatio: 0.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := &countingGetter{info: found}
+			store := &mapStore{err: tt.storeErr}
+			cache, now := newTestCache(getter, store, time.Hour, time.Hour, 10)
+			if tt.stored {
+				store.SaveCachedInfo("muse", "uprising", postgres.CachedInfo{
+					Found: true, Text: "lyrics", Sources: postgres.Sources{"text": "api"}, ExpiresAt: now.Add(time.Hour),
+				})
+			}
+
+			for range 2 {
+				info, err := cache.GetInfo(context.Background(), "Muse", "Uprising")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if info.Text != found.Text || info.Sources["text"] != "api" {
+					t.Errorf("GetInfo() = %+v, want %+v", info, found)
+				}
+			}
+
+			if got := getter.calls.Load(); got != tt.calls {
+				t.Errorf("getter calls = %d, want %d", got, tt.calls)
+			}
+			tt.stats.Capacity = 10
+			if got := cache.Stats(); got != tt.stats {
+				t.Errorf("Stats() = %+v, want %+v", got, tt.stats)
+			}
+			if tt.storeErr == nil {
+				if _, err := store.GetCachedInfo("muse", "uprising"); err != nil {
+					t.Errorf("persistent tier lacks the song: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheSharesConcurrentMisses(t *testing.T) {
+	const callers = 5
+
+	getter := &countingGetter{
+		info:    SongDetail{Text: "lyrics"},
+		release: make(chan struct{}),
+		started: make(chan struct{}, callers),
+	}
+	cache, _ := newTestCache(getter, nil, time.Hour, time.Hour, 10)
+
+	var wg sync.WaitGroup
+	lookup := func() {
+		defer wg.Done()
+		if info, err := cache.GetInfo(context.Background(), "Muse", "Uprising"); err != nil || info.Text != "lyrics" {
+			t.Errorf("GetInfo() = %+v, %v", info, err)
+		}
+	}
+
+	wg.Add(1)
+	go lookup()
+	<-getter.started
+
+	wg.Add(callers - 1)
+	for range callers - 1 {
+		go lookup()
+	}
+	// the other callers wait for the lookup in flight
+	for waiting(&cache.flight) < callers-1 {
+		time.Sleep(time.Millisecond)
+	}
+	close(getter.release)
+	wg.Wait()
+
+	if got := getter.calls.Load(); got != 1 {
+		t.Errorf("getter calls = %d, want 1", got)
+	}
+	if got := cache.Stats(); got.Misses != 1 || got.Shared != callers-1 {
+		t.Errorf("Stats() = %+v, want 1 miss and %d shared", got, callers-1)
+	}
+}
+
+// waiting returns the number of callers waiting for a call in flight
+func waiting(f *flight) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n := 0
+	for _, c := range f.calls {
+		n += c.waiting
+	}
+	return n
+}
@@ -0,0 +1,161 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNoProviders  = errors.New("no enrichment providers")
+	ErrInvalidRules = errors.New("invalid enrichment field rules")
+	ErrDegraded     = errors.New("some enrichment providers failed")
+)
+
+// fields are the attributes of a song merged by the chain
+var fields = []struct {
+	name string
+	get  func(SongDetail) string
+	set  func(*SongDetail, string)
+}{
+	{"release_date", func(d SongDetail) string { return d.ReleaseDate }, func(d *SongDetail, v string) { d.ReleaseDate = v }},
+	{"text", func(d SongDetail) string { return d.Text }, func(d *SongDetail, v string) { d.Text = v }},
+	{"link", func(d SongDetail) string { return d.Link }, func(d *SongDetail, v string) { d.Link = v }},
+}
+
+// Chain merges the information about a song given by several providers.
+// Every attribute is taken from the first provider that knows it, in the
+// order of the rule of the attribute or in the order of the providers.
+// Providers that fail or do not know the song are skipped, a merge that
+// skipped a failed provider is degraded.
+type Chain struct {
+	log       *logger.Logger
+	providers map[string]Provider
+	order     []string
+	rules     map[string][]string
+}
+
+// NewChain returns the chain of the providers in their priority order with
+// the field rules as in "release_date=file,api;link=api,file"
+func NewChain(log *logger.Logger, providers []Provider, rules string) (*Chain, error) {
+	const op = "enrichment.NewChain"
+
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, ErrNoProviders)
+	}
+
+	c := &Chain{
+		log:       log,
+		providers: make(map[string]Provider, len(providers)),
+		rules:     make(map[string][]string),
+	}
+	for _, p := range providers {
+		c.providers[p.Name()] = p
+		c.order = append(c.order, p.Name())
+	}
+
+	for _, rule := range strings.Split(rules, ";") {
+		if strings.TrimSpace(rule) == "" {
+			continue
+		}
+		field, names, ok := strings.Cut(rule, "=")
+		field = strings.TrimSpace(field)
+		if !ok || !knownField(field) {
+			return nil, fmt.Errorf("%s: %w: %q", op, ErrInvalidRules, rule)
+		}
+
+		order := make([]string, 0)
+		for _, name := range strings.Split(names, ",") {
+			name = strings.TrimSpace(name)
+			if _, ok := c.providers[name]; !ok {
+				return nil, fmt.Errorf("%s: %w: %q", op, ErrUnknownProvider, name)
+			}
+			order = append(order, name)
+		}
+		c.rules[field] = order
+	}
+
+	return c, nil
+}
+
+// GetInfo gets full information about the song merged from the providers,
+// the sources of the result name the provider of every attribute. The song
+// is unknown only when no provider failed, otherwise the failure is returned.
+func (c *Chain) GetInfo(ctx context.Context, group, song string) (SongDetail, error) {
+	const op = "enrichment.Chain.GetInfo"
+
+	type result struct {
+		info SongDetail
+		err  error
+	}
+	results := make(map[string]result)
+	lookup := func(name string) result {
+		r, ok := results[name]
+		if !ok {
+			r.info, r.err = c.providers[name].GetInfo(ctx, group, song)
+			if r.err != nil && !errors.Is(r.err, ErrNotFound) {
+				c.log.LogError("enrichment provider failed", zap.String("op", op),
+					zap.String("provider", name),
+					zap.Error(r.err))
+			}
+			results[name] = r
+		}
+		return r
+	}
+
+	info := SongDetail{Sources: make(postgres.Sources)}
+	for _, f := range fields {
+		for _, name := range c.fieldOrder(f.name) {
+			r := lookup(name)
+			if r.err != nil {
+				continue
+			}
+			if v := f.get(r.info); v != "" {
+				f.set(&info, v)
+				info.Sources[f.name] = name
+				break
+			}
+		}
+	}
+
+	// a failed provider might have known the song or given other attributes
+	var failed error
+	for _, name := range c.order {
+		if r, ok := results[name]; ok && r.err != nil && !errors.Is(r.err, ErrNotFound) {
+			failed = fmt.Errorf("%s: %s: %w", op, name, r.err)
+			break
+		}
+	}
+
+	switch {
+	case len(info.Sources) > 0:
+		info.Degraded = failed != nil
+		return info, nil
+	case failed != nil:
+		return SongDetail{}, failed
+	default:
+		return SongDetail{}, fmt.Errorf("%s: %w", op, ErrNotFound)
+	}
+}
+
+// fieldOrder returns the order the providers are tried in for the field
+func (c *Chain) fieldOrder(field string) []string {
+	if order, ok := c.rules[field]; ok {
+		return order
+	}
+	return c.order
+}
+
+func knownField(name string) bool {
+	for _, f := range fields {
+		if f.name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"go.uber.org/zap"
+)
+
+// stubProvider is a provider answering every song the same way
+type stubProvider struct {
+	name string
+	info SongDetail
+	err  error
+}
+
+func (p stubProvider) Name() string { return p.name }
+
+func (p stubProvider) GetInfo(context.Context, string, string) (SongDetail, error) {
+	return p.info, p.err
+}
+
+func TestChainGetInfo(t *testing.T) {
+	errDown := errors.New("connection refused")
+
+	api := stubProvider{name: "api", info: SongDetail{ReleaseDate: "16.07.2006", Text: "lyrics", Link: "http://api"}}
+	apiDown := stubProvider{name: "api", err: errDown}
+	apiUnknown := stubProvider{name: "api", err: ErrNotFound}
+	file := stubProvider{name: "file", info: SongDetail{ReleaseDate: "19.06.2006"}}
+	fileUnknown := stubProvider{name: "file", err: ErrNotFound}
+	static := stubProvider{name: "static", info: SongDetail{Link: "http://static"}}
+
+	tests := []struct {
+		name      string
+		providers []Provider
+		rules     string
+		want      SongDetail
+		err       error
+	}{
+		{
+			name:      "first provider wins",
+			providers: []Provider{api, file, static},
+			want: SongDetail{ReleaseDate: "16.07.2006", Text: "lyrics", Link: "http://api",
+				Sources: postgres.Sources{"release_date": "api", "text": "api", "link": "api"}},
+		},
+		{
+			name:      "field rules",
+			providers: []Provider{api, file, static},
+			rules:     "release_date=file,api; link=static",
+			want: SongDetail{ReleaseDate: "19.06.2006", Text: "lyrics", Link: "http://static",
+				Sources: postgres.Sources{"release_date": "file", "text": "api", "link": "static"}},
+		},
+		{
+			name:      "failed provider falls back",
+			providers: []Provider{apiDown, file, static},
+			want: SongDetail{ReleaseDate: "19.06.2006", Link: "http://static",
+				Sources: postgres.Sources{"release_date": "file", "link": "static"}, Degraded: true},
+		},
+		{
+			name:      "unneeded unknown provider",
+			providers: []Provider{apiUnknown, file},
+			want: SongDetail{ReleaseDate: "19.06.2006",
+				Sources: postgres.Sources{"release_date": "file"}},
+		},
+		{
+			name:      "unknown everywhere",
+			providers: []Provider{apiUnknown, fileUnknown},
+			err:       ErrNotFound,
+		},
+		{
+			name:      "unknown to one and failed in another",
+			providers: []Provider{apiDown, fileUnknown},
+			err:       errDown,
+		},
+		{
+			name:      "failed everywhere",
+			providers: []Provider{apiDown},
+			err:       errDown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewChain(&logger.Logger{Logger: zap.NewNop()}, tt.providers, tt.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := c.GetInfo(context.Background(), "Muse", "Supermassive Black Hole")
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("GetInfo() error = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetInfo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewChainRules(t *testing.T) {
+	providers := []Provider{stubProvider{name: "api"}, stubProvider{name: "file"}}
+
+	tests := []struct {
+		rules string
+		err   error
+	}{
+		{rules: "", err: nil},
+		{rules: "release_date=file,api;link=api", err: nil},
+		{rules: "year=file", err: ErrInvalidRules},
+		{rules: "link", err: ErrInvalidRules},
+		{rules: "link=static", err: ErrUnknownProvider},
+	}
+
+	for _, tt := range tests {
+		_, err := NewChain(&logger.Logger{Logger: zap.NewNop()}, providers, tt.rules)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("NewChain(%q) error = %v, want %v", tt.rules, err, tt.err)
+		}
+	}
+	if _, err := NewChain(&logger.Logger{Logger: zap.NewNop()}, nil, ""); !errors.Is(err, ErrNoProviders) {
+		t.Errorf("NewChain() without providers error = %v, want %v", err, ErrNoProviders)
+	}
+}
@@ -0,0 +1,233 @@
+package enrichment
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/foreground-eclipse/song-library/internal/config"
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNotFound   = errors.New("song info not found")
+	ErrInvalidURL = errors.New("invalid enrichment base url")
+)
+
+// SongDetail is the information about a song given by the music info API
+type SongDetail struct {
+	ReleaseDate string `json:"release_date"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+	// Sources are the providers the attributes were taken from
+	Sources postgres.Sources `json:"-"`
+	// Degraded tells some providers failed, the info may lack attributes
+	// they would have given
+	Degraded bool `json:"-"`
+}
+
+// StatusError is an unexpected response status of the music info API
+type StatusError struct {
+	Code int
+	// RetryAfter is the delay before a retry asked by the API
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("music info api responded with %d %s", e.Code, http.StatusText(e.Code))
+}
+
+// Client is a client of the music info API
+type Client struct {
+	log        *logger.Logger
+	infoURL    *url.URL
+	http       *http.Client
+	authHeader string
+	authToken  string
+	backoff    Backoff
+	breaker    *Breaker
+}
+
+// New returns the client of the music info API configured by cfg
+func New(log *logger.Logger, cfg config.Enrichment) (*Client, error) {
+	const op = "enrichment.New"
+
+	base, err := url.Parse(cfg.BaseURL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("%s: %w: %q", op, ErrInvalidURL, cfg.BaseURL)
+	}
+
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &Client{
+		log:     log,
+		infoURL: base.JoinPath("info"),
+		http: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: transport,
+		},
+		authHeader: cfg.AuthHeader,
+		authToken:  cfg.AuthToken,
+		backoff: Backoff{
+			Attempts:  max(cfg.RetryAttempts, 1),
+			BaseDelay: cfg.RetryBaseDelay,
+			MaxDelay:  cfg.RetryMaxDelay,
+		},
+		breaker: NewBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+	}, nil
+}
+
+func newTLSConfig(cfg config.Enrichment) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.TLSInsecureSkipVerify,
+	}
+
+	if cfg.TLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", cfg.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// GetInfo gets full information about the song. Failures of an unhealthy
+// API are retried with backoff and open the circuit breaker, which fails
+// further calls fast with ErrCircuitOpen. A retry waits at least as long as
+// the Retry-After header asks, a call is not retried when the API asks to
+// wait longer than the longest backoff delay.
+func (c *Client) GetInfo(ctx context.Context, group, song string) (SongDetail, error) {
+	const op = "enrichment.Client.GetInfo"
+
+	c.log.LogInfo("trying to get full info", zap.String("op", op),
+		zap.String("group", group),
+		zap.String("song", song))
+
+	if err := c.breaker.Allow(); err != nil {
+		return SongDetail{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var info SongDetail
+	var err error
+	for attempt := 0; ; attempt++ {
+		info, err = c.getInfo(ctx, group, song)
+		if err == nil || !retryable(err) || attempt+1 >= c.backoff.Attempts {
+			break
+		}
+
+		var status *StatusError
+		var wait time.Duration
+		if errors.As(err, &status) {
+			wait = status.RetryAfter
+		}
+		if wait > c.backoff.MaxDelay {
+			break
+		}
+		wait = c.backoff.delay(attempt, wait)
+		c.log.LogDebug("retrying to get full info", zap.String("op", op),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("delay", wait),
+			zap.Error(err))
+
+		if serr := sleep(ctx, wait); serr != nil {
+			err = serr
+			break
+		}
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		// the caller gave up, the call tells nothing about the API
+		c.breaker.Release()
+	case unhealthy(err):
+		c.breaker.Failure()
+	default:
+		// the API answered, even if it does not know the song
+		c.breaker.Success()
+	}
+	if err != nil {
+		return SongDetail{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.log.LogDebug("got the full information", zap.String("op", op),
+		zap.String("group", group),
+		zap.String("song", song))
+
+	return info, nil
+}
+
+// Name returns the name of the music info API provider
+func (c *Client) Name() string {
+	return ProviderAPI
+}
+
+// getInfo makes a single call of the API
+func (c *Client) getInfo(ctx context.Context, group, song string) (SongDetail, error) {
+	u := *c.infoURL
+	u.RawQuery = url.Values{"group": {group}, "song": {song}}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return SongDetail{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.authToken != "" {
+		req.Header.Set(c.authHeader, c.authToken)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		if timedOut(err) {
+			return SongDetail{}, fmt.Errorf("%w: %w", ErrTimeout, err)
+		}
+		return SongDetail{}, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return SongDetail{}, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return SongDetail{}, &StatusError{Code: resp.StatusCode, RetryAfter: retryAfter(resp)}
+	}
+
+	var info SongDetail
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		if timedOut(err) {
+			return SongDetail{}, fmt.Errorf("%w: %w", ErrTimeout, err)
+		}
+		return SongDetail{}, err
+	}
+
+	return info, nil
+}
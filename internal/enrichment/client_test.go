@@ -0,0 +1,141 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/foreground-eclipse/song-library/internal/config"
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"go.uber.org/zap"
+)
+
+// newTestClient returns a client of the server with a breaker opened by a
+// single failure and no retries
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	c, err := New(&logger.Logger{Logger: zap.NewNop()}, config.Enrichment{
+		BaseURL:          srv.URL,
+		Timeout:          time.Second,
+		RetryAttempts:    1,
+		BreakerThreshold: 1,
+		BreakerCooldown:  time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestClientResolvesTrialCall(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		cancel bool
+		state  breakerState
+	}{
+		{name: "found", status: http.StatusOK, state: breakerClosed},
+		{name: "not found", status: http.StatusNotFound, state: breakerClosed},
+		{name: "bad request", status: http.StatusBadRequest, state: breakerClosed},
+		{name: "too many requests", status: http.StatusTooManyRequests, state: breakerClosed},
+		{name: "server error", status: http.StatusInternalServerError, state: breakerOpen},
+		{name: "canceled", status: http.StatusOK, cancel: true, state: breakerOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				if tt.status == http.StatusOK {
+					w.Write([]byte(`{"text": "lyrics"}`))
+				}
+			})
+
+			// a failure opens the circuit and the cooldown passes
+			c.breaker.Failure()
+			c.breaker.openedAt = time.Now().Add(-time.Hour)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.cancel {
+				cancel()
+			}
+			defer cancel()
+			c.GetInfo(ctx, "Muse", "Supermassive Black Hole")
+
+			if c.breaker.state != tt.state {
+				t.Errorf("state = %v, want %v", c.breaker.state, tt.state)
+			}
+			if err := c.breaker.Allow(); tt.cancel && err != nil {
+				t.Errorf("Allow() after a canceled trial = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestClientCanceledBackoff(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	c.backoff = Backoff{Attempts: 3, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	c.breaker = NewBreaker(1, time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	// the backoff sleep outlasts the context
+	_, err := c.GetInfo(ctx, "Muse", "Supermassive Black Hole")
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetInfo() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if c.breaker.state != breakerClosed {
+		t.Errorf("state = %v, want closed", c.breaker.state)
+	}
+}
+
+func TestClientRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		wantCalls  int
+		wantErr    bool
+		minElapsed time.Duration
+	}{
+		{name: "waits as asked", retryAfter: "1", wantCalls: 2, minElapsed: time.Second},
+		{name: "asked to wait too long", retryAfter: "120", wantCalls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls == 1 {
+					w.Header().Set("Retry-After", tt.retryAfter)
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				w.Write([]byte(`{"text": "lyrics"}`))
+			})
+			c.backoff = Backoff{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Second}
+
+			start := time.Now()
+			_, err := c.GetInfo(context.Background(), "Muse", "Supermassive Black Hole")
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetInfo() error = %v, want error %t", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if elapsed := time.Since(start); elapsed < tt.minElapsed {
+				t.Errorf("GetInfo() took %v, want at least %v", elapsed, tt.minElapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package enrichment
+
+import "sync"
+
+// flight shares a call among the concurrent callers asking for the same key,
+// the first caller makes the call and the others wait for its result
+type flight struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done   chan struct{}
+	detail SongDetail
+	err    error
+	// waiting is the number of callers sharing the result
+	waiting int
+}
+
+// do makes the call unless a call with the same key is in flight, it reports
+// whether the result was shared
+func (f *flight) do(key string, call func() (SongDetail, error)) (SongDetail, error, bool) {
+	f.mu.Lock()
+	if f.calls == nil {
+		f.calls = make(map[string]*flightCall)
+	}
+	if c, ok := f.calls[key]; ok {
+		c.waiting++
+		f.mu.Unlock()
+		<-c.done
+		return c.detail, c.err, true
+	}
+	c := &flightCall{done: make(chan struct{})}
+	f.calls[key] = c
+	f.mu.Unlock()
+
+	defer func() {
+		f.mu.Lock()
+		delete(f.calls, key)
+		f.mu.Unlock()
+		close(c.done)
+	}()
+
+	c.detail, c.err = call()
+	return c.detail, c.err, false
+}
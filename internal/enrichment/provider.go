@@ -0,0 +1,203 @@
+package enrichment
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/foreground-eclipse/song-library/internal/config"
+)
+
+// Names of the providers
+const (
+	ProviderAPI    = "api"
+	ProviderFile   = "file"
+	ProviderStatic = "static"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown enrichment provider")
+	ErrInvalidMetadata = errors.New("invalid metadata file")
+)
+
+// Provider is a source of information about songs
+type Provider interface {
+	Name() string
+	GetInfo(ctx context.Context, group, song string) (SongDetail, error)
+}
+
+// NewProviders returns the providers named by cfg in their order, the
+// client is the music info API provider
+func NewProviders(cfg config.Enrichment, client *Client) ([]Provider, error) {
+	const op = "enrichment.NewProviders"
+
+	providers := make([]Provider, 0, len(cfg.Providers))
+	for _, name := range cfg.Providers {
+		switch strings.TrimSpace(name) {
+		case ProviderAPI:
+			providers = append(providers, client)
+		case ProviderFile:
+			file, err := LoadFile(cfg.MetadataFile)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			providers = append(providers, file)
+		case ProviderStatic:
+			providers = append(providers, NewStatic(cfg))
+		default:
+			return nil, fmt.Errorf("%s: %w: %q", op, ErrUnknownProvider, name)
+		}
+	}
+
+	return providers, nil
+}
+
+// File is a provider of the songs of a local metadata file
+type File struct {
+	songs map[string]SongDetail
+}
+
+// fileRecord is a song of a metadata file
+type fileRecord struct {
+	Group       string `json:"group"`
+	Song        string `json:"song"`
+	ReleaseDate string `json:"release_date"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+}
+
+// LoadFile loads the metadata file with a JSON array of songs or a CSV table
+// with a header naming the columns group, song, release_date, text and link
+func LoadFile(path string) (*File, error) {
+	const op = "enrichment.LoadFile"
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer f.Close()
+
+	var records []fileRecord
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.NewDecoder(f).Decode(&records)
+	case ".csv":
+		records, err = readCSV(f)
+	default:
+		err = fmt.Errorf("%w: %s is neither .json nor .csv", ErrInvalidMetadata, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	file := &File{songs: make(map[string]SongDetail, len(records))}
+	for _, r := range records {
+		file.songs[cacheKey(r.Group)+"\x00"+cacheKey(r.Song)] = SongDetail{
+			ReleaseDate: r.ReleaseDate,
+			Text:        r.Text,
+			Link:        r.Link,
+		}
+	}
+
+	return file, nil
+}
+
+// readCSV reads the songs of a CSV table, unknown columns are ignored
+func readCSV(r io.Reader) ([]fileRecord, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: no header", ErrInvalidMetadata)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"group", "song"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: no %s column", ErrInvalidMetadata, name)
+		}
+	}
+
+	value := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	records := make([]fileRecord, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		records = append(records, fileRecord{
+			Group:       value(row, "group"),
+			Song:        value(row, "song"),
+			ReleaseDate: value(row, "release_date"),
+			Text:        value(row, "text"),
+			Link:        value(row, "link"),
+		})
+	}
+
+	return records, nil
+}
+
+// Name returns the name of the metadata file provider
+func (f *File) Name() string {
+	return ProviderFile
+}
+
+// GetInfo gets the song of the metadata file
+func (f *File) GetInfo(_ context.Context, group, song string) (SongDetail, error) {
+	const op = "enrichment.File.GetInfo"
+
+	info, ok := f.songs[cacheKey(group)+"\x00"+cacheKey(song)]
+	if !ok {
+		return SongDetail{}, fmt.Errorf("%s: %w", op, ErrNotFound)
+	}
+	return info, nil
+}
+
+// Static is a provider of the same information about every song, the
+// {group} and {song} placeholders of the link are replaced with the escaped
+// names of the song
+type Static struct {
+	info SongDetail
+}
+
+// NewStatic returns the static provider configured by cfg
+func NewStatic(cfg config.Enrichment) *Static {
+	return &Static{info: SongDetail{
+		ReleaseDate: cfg.StaticReleaseDate,
+		Text:        cfg.StaticText,
+		Link:        cfg.StaticLink,
+	}}
+}
+
+// Name returns the name of the static provider
+func (s *Static) Name() string {
+	return ProviderStatic
+}
+
+// GetInfo gets the static information about the song
+func (s *Static) GetInfo(_ context.Context, group, song string) (SongDetail, error) {
+	const op = "enrichment.Static.GetInfo"
+
+	info := s.info
+	if info.ReleaseDate == "" && info.Text == "" && info.Link == "" {
+		return SongDetail{}, fmt.Errorf("%s: %w", op, ErrNotFound)
+	}
+	info.Link = strings.NewReplacer(
+		"{group}", url.QueryEscape(group),
+		"{song}", url.QueryEscape(song),
+	).Replace(info.Link)
+	return info, nil
+}
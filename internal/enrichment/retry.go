@@ -0,0 +1,90 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var ErrTimeout = errors.New("music info api timed out")
+
+// Backoff is the policy of retrying failed calls with exponentially growing
+// delays randomized by full jitter
+type Backoff struct {
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// delay returns the delay before the retry following the failed attempt,
+// numbered from 0, waiting at least as long as the server asked
+func (b Backoff) delay(attempt int, retryAfter time.Duration) time.Duration {
+	ceiling := b.MaxDelay
+	if attempt < 32 {
+		ceiling = min(b.BaseDelay<<attempt, b.MaxDelay)
+	}
+	if ceiling <= 0 {
+		return max(retryAfter, 0)
+	}
+	return max(time.Duration(rand.Int63n(int64(ceiling)+1)), retryAfter)
+}
+
+// sleep waits for the delay unless the context is done first
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// retryable reports whether the failed call may succeed when repeated, the
+// failure tells the upstream is unhealthy then
+func retryable(err error) bool {
+	var status *StatusError
+	if errors.As(err, &status) {
+		return status.Code == http.StatusTooManyRequests || status.Code >= http.StatusInternalServerError
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var netErr net.Error
+	return errors.Is(err, ErrTimeout) || errors.As(err, &netErr)
+}
+
+// unhealthy reports whether the error shows the API failing rather than
+// answering: a server error, a timeout or a broken connection
+func unhealthy(err error) bool {
+	if err == nil {
+		return false
+	}
+	var status *StatusError
+	if errors.As(err, &status) {
+		return status.Code >= http.StatusInternalServerError
+	}
+	return retryable(err)
+}
+
+// timedOut reports whether the call failed by a timeout
+func timedOut(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retryAfter returns the delay asked by the Retry-After header in seconds
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,111 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Attempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		name       string
+		attempt    int
+		retryAfter time.Duration
+		ceiling    time.Duration
+	}{
+		{name: "first retry", attempt: 0, ceiling: 100 * time.Millisecond},
+		{name: "grows exponentially", attempt: 2, ceiling: 400 * time.Millisecond},
+		{name: "capped", attempt: 5, ceiling: time.Second},
+		{name: "shift overflow", attempt: 64, ceiling: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 100 {
+				if d := b.delay(tt.attempt, tt.retryAfter); d < 0 || d > tt.ceiling {
+					t.Fatalf("delay(%d) = %v, want within [0, %v]", tt.attempt, d, tt.ceiling)
+				}
+			}
+		})
+	}
+}
+
+func TestBackoffRetryAfter(t *testing.T) {
+	b := Backoff{Attempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		name       string
+		backoff    Backoff
+		retryAfter time.Duration
+		want       time.Duration
+	}{
+		{name: "longer than the backoff", backoff: b, retryAfter: 500 * time.Millisecond, want: 500 * time.Millisecond},
+		{name: "longer than the longest backoff", backoff: b, retryAfter: time.Minute, want: time.Minute},
+		{name: "no base delay", backoff: Backoff{}, retryAfter: 0, want: 0},
+		{name: "no base delay and retry after", backoff: Backoff{}, retryAfter: time.Second, want: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.backoff.delay(0, tt.retryAfter); got != tt.want {
+				t.Errorf("delay(0, %v) = %v, want %v", tt.retryAfter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantRetryable bool
+		wantUnhealthy bool
+	}{
+		{name: "no error", err: nil},
+		{name: "too many requests", err: &StatusError{Code: http.StatusTooManyRequests}, wantRetryable: true},
+		{name: "server error", err: &StatusError{Code: http.StatusBadGateway}, wantRetryable: true, wantUnhealthy: true},
+		{name: "wrapped server error", err: fmt.Errorf("get: %w", &StatusError{Code: http.StatusInternalServerError}),
+			wantRetryable: true, wantUnhealthy: true},
+		{name: "not found", err: &StatusError{Code: http.StatusNotFound}},
+		{name: "timeout", err: ErrTimeout, wantRetryable: true, wantUnhealthy: true},
+		{name: "broken connection", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")},
+			wantRetryable: true, wantUnhealthy: true},
+		{name: "canceled", err: context.Canceled},
+		{name: "other error", err: errors.New("invalid json")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable(tt.err); got != tt.wantRetryable {
+				t.Errorf("retryable() = %t, want %t", got, tt.wantRetryable)
+			}
+			if got := unhealthy(tt.err); got != tt.wantUnhealthy {
+				t.Errorf("unhealthy() = %t, want %t", got, tt.wantUnhealthy)
+			}
+		})
+	}
+}
+
+func TestRetryAfterHeader(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{header: "", want: 0},
+		{header: "3", want: 3 * time.Second},
+		{header: "-1", want: 0},
+		{header: "Wed, 21 Oct 2015 07:28:00 GMT", want: 0},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.header != "" {
+			resp.Header.Set("Retry-After", tt.header)
+		}
+		if got := retryAfter(resp); got != tt.want {
+			t.Errorf("retryAfter(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/foreground-eclipse/song-library/internal/config"
+	"github.com/foreground-eclipse/song-library/internal/lib/releasedate"
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"go.uber.org/zap"
+)
+
+// JobAuthor is the author of the revisions made by enrichment jobs
+const JobAuthor = "enrichment"
+
+type InfoGetter interface {
+	GetInfo(ctx context.Context, group, song string) (SongDetail, error)
+}
+
+type JobQueue interface {
+	ClaimEnrichmentJob() (postgres.EnrichmentJob, error)
+	CompleteEnrichmentJob(id string, patch postgres.SongPatch, change postgres.Change) (postgres.Song, error)
+	RetryEnrichmentJob(id, cause string, delay time.Duration) error
+	ReleaseEnrichmentJob(id string) error
+	FailEnrichmentJob(id, cause string) error
+	RequeueStaleEnrichmentJobs(timeout time.Duration) (int64, error)
+}
+
+// Worker processes the enrichment jobs of the queue
+type Worker struct {
+	log          *logger.Logger
+	queue        JobQueue
+	getter       InfoGetter
+	pollInterval time.Duration
+	attempts     int
+	backoff      Backoff
+}
+
+// NewWorker returns the worker looking up the info of queued songs with the
+// getter
+func NewWorker(log *logger.Logger, queue JobQueue, getter InfoGetter, cfg config.Enrichment) *Worker {
+	return &Worker{
+		log:          log,
+		queue:        queue,
+		getter:       getter,
+		pollInterval: cfg.PollInterval,
+		attempts:     max(cfg.JobAttempts, 1),
+		backoff: Backoff{
+			BaseDelay: cfg.JobRetryDelay,
+			MaxDelay:  cfg.JobRetryMaxDelay,
+		},
+	}
+}
+
+// Run processes the jobs with the given number of concurrent workers and
+// requeues the jobs left running for longer than the timeout until the
+// context is done
+func (w *Worker) Run(ctx context.Context, workers int, timeout time.Duration) {
+	const op = "enrichment.Worker.Run"
+
+	if workers <= 0 {
+		w.log.LogInfo("enrichment workers are disabled", zap.String("op", op))
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.work(ctx)
+		}()
+	}
+
+	if timeout > 0 {
+		ticker := time.NewTicker(timeout)
+		defer ticker.Stop()
+
+	loop:
+		for {
+			n, err := w.queue.RequeueStaleEnrichmentJobs(timeout)
+			if err != nil {
+				w.log.LogError("failed to requeue stale enrichment jobs", zap.String("op", op),
+					zap.Error(err))
+			} else if n > 0 {
+				w.log.LogInfo("requeued stale enrichment jobs", zap.String("op", op),
+					zap.Int64("jobs", n))
+			}
+
+			select {
+			case <-ctx.Done():
+				break loop
+			case <-ticker.C:
+			}
+		}
+	}
+
+	wg.Wait()
+}
+
+// work claims and processes jobs, polling the queue while it is empty
+func (w *Worker) work(ctx context.Context) {
+	const op = "enrichment.Worker.work"
+
+	for ctx.Err() == nil {
+		job, err := w.queue.ClaimEnrichmentJob()
+		if err == nil {
+			w.process(ctx, job)
+			continue
+		}
+		if !errors.Is(err, postgres.ErrNoEnrichmentJobs) {
+			w.log.LogError("failed to claim an enrichment job", zap.String("op", op),
+				zap.Error(err))
+		}
+
+		if sleep(ctx, w.pollInterval) != nil {
+			return
+		}
+	}
+}
+
+// process looks up the info of the song of the job and applies it, failed
+// and degraded lookups are retried with backoff until the attempts run out,
+// the last attempt applies degraded info
+func (w *Worker) process(ctx context.Context, job postgres.EnrichmentJob) {
+	const op = "enrichment.Worker.process"
+
+	log := []zap.Field{zap.String("op", op), zap.String("job", job.ID),
+		zap.String("song_id", job.SongID), zap.Int("attempt", job.Attempts)}
+
+	info, err := w.getter.GetInfo(ctx, job.Group, job.Song)
+	if err == nil && info.Degraded && job.Attempts < w.attempts {
+		// the failed providers may complete the info on a retry
+		err = ErrDegraded
+	}
+	if err == nil {
+		_, err = w.queue.CompleteEnrichmentJob(job.ID, w.patch(info), postgres.Change{
+			Version: job.Version,
+			Author:  JobAuthor,
+		})
+		if err == nil {
+			w.log.LogInfo("enriched the song", log...)
+			return
+		}
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		// the worker is stopping, the attempt does not count against the job
+		err = w.queue.ReleaseEnrichmentJob(job.ID)
+	case errors.Is(err, ErrNotFound), errors.Is(err, postgres.ErrNotFound), job.Attempts >= w.attempts:
+		w.log.LogError("failed to enrich the song", append(log, zap.Error(err))...)
+		err = w.queue.FailEnrichmentJob(job.ID, err.Error())
+	default:
+		delay := w.backoff.delay(job.Attempts-1, 0)
+		w.log.LogError("retrying to enrich the song", append(log, zap.Error(err),
+			zap.Duration("delay", delay))...)
+		err = w.queue.RetryEnrichmentJob(job.ID, err.Error(), delay)
+	}
+	if err != nil {
+		w.log.LogError("failed to update the enrichment job", append(log, zap.Error(err))...)
+	}
+}
+
+// patch returns the attributes of the song given by the info, an invalid
+// release date is dropped
+func (w *Worker) patch(info SongDetail) postgres.SongPatch {
+	const op = "enrichment.Worker.patch"
+
+	patch := postgres.SongPatch{Sources: info.Sources}
+	if info.Text != "" {
+		patch.Text = &info.Text
+	}
+	if info.Link != "" {
+		patch.Link = &info.Link
+	}
+	if info.ReleaseDate != "" {
+		date, err := releasedate.Parse(info.ReleaseDate)
+		if err != nil {
+			w.log.LogError("dropping the release date of the song", zap.String("op", op),
+				zap.Error(err))
+		} else {
+			patch.ReleaseDate = &date
+		}
+	}
+	return patch
+}
@@ -0,0 +1,107 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/foreground-eclipse/song-library/internal/config"
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"go.uber.org/zap"
+)
+
+// recordingQueue records how the worker settles a job
+type recordingQueue struct {
+	completeErr error
+
+	outcome string
+	change  postgres.Change
+	delay   time.Duration
+}
+
+func (q *recordingQueue) ClaimEnrichmentJob() (postgres.EnrichmentJob, error) {
+	return postgres.EnrichmentJob{}, postgres.ErrNoEnrichmentJobs
+}
+
+func (q *recordingQueue) CompleteEnrichmentJob(_ string, _ postgres.SongPatch, change postgres.Change) (postgres.Song, error) {
+	q.change = change
+	if q.completeErr != nil {
+		return postgres.Song{}, q.completeErr
+	}
+	q.outcome = "complete"
+	return postgres.Song{}, nil
+}
+
+func (q *recordingQueue) RetryEnrichmentJob(_, _ string, delay time.Duration) error {
+	q.outcome, q.delay = "retry", delay
+	return nil
+}
+
+func (q *recordingQueue) ReleaseEnrichmentJob(string) error {
+	q.outcome = "release"
+	return nil
+}
+
+func (q *recordingQueue) FailEnrichmentJob(string, string) error {
+	q.outcome = "fail"
+	return nil
+}
+
+func (q *recordingQueue) RequeueStaleEnrichmentJobs(time.Duration) (int64, error) {
+	return 0, nil
+}
+
+func TestWorkerProcess(t *testing.T) {
+	errDown := errors.New("connection refused")
+	found := stubProvider{name: "api", info: SongDetail{Text: "lyrics"}}
+	degraded := stubProvider{name: "api", info: SongDetail{Text: "lyrics", Degraded: true}}
+
+	tests := []struct {
+		name        string
+		getter      InfoGetter
+		completeErr error
+		attempts    int
+		canceled    bool
+		want        string
+		maxDelay    time.Duration
+	}{
+		{name: "found", getter: found, attempts: 1, want: "complete"},
+		{name: "unknown song", getter: stubProvider{err: ErrNotFound}, attempts: 1, want: "fail"},
+		{name: "song gone", getter: found, completeErr: postgres.ErrNotFound, attempts: 1, want: "fail"},
+		{name: "first failure", getter: stubProvider{err: errDown}, attempts: 1, want: "retry", maxDelay: time.Second},
+		{name: "second failure", getter: stubProvider{err: errDown}, attempts: 2, want: "retry", maxDelay: 2 * time.Second},
+		{name: "last attempt", getter: stubProvider{err: errDown}, attempts: 3, want: "fail"},
+		{name: "degraded", getter: degraded, attempts: 1, want: "retry", maxDelay: time.Second},
+		{name: "degraded at the last attempt", getter: degraded, attempts: 3, want: "complete"},
+		{name: "stopping", getter: stubProvider{err: context.Canceled}, attempts: 3, canceled: true, want: "release"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := &recordingQueue{completeErr: tt.completeErr}
+			w := NewWorker(&logger.Logger{Logger: zap.NewNop()}, queue, tt.getter, config.Enrichment{
+				JobAttempts:      3,
+				JobRetryDelay:    time.Second,
+				JobRetryMaxDelay: time.Minute,
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.canceled {
+				cancel()
+			}
+
+			w.process(ctx, postgres.EnrichmentJob{ID: "job", Attempts: tt.attempts, Version: 7})
+			if queue.outcome != tt.want {
+				t.Fatalf("job outcome = %q, want %q", queue.outcome, tt.want)
+			}
+			if queue.delay > tt.maxDelay {
+				t.Errorf("retry delay = %v, want at most %v", queue.delay, tt.maxDelay)
+			}
+			if tt.want == "complete" && queue.change.Version != 7 {
+				t.Errorf("completed with version %d, want the claimed version 7", queue.change.Version)
+			}
+		})
+	}
+}
@@ -1,11 +1,15 @@
 package addsong
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"net/http"
 
+	"github.com/foreground-eclipse/song-library/internal/enrichment"
+	"github.com/foreground-eclipse/song-library/internal/lib/api/author"
+	"github.com/foreground-eclipse/song-library/internal/lib/api/etag"
 	"github.com/foreground-eclipse/song-library/internal/lib/api/response"
+	"github.com/foreground-eclipse/song-library/internal/lib/releasedate"
 	"github.com/foreground-eclipse/song-library/internal/logger"
 	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
 	"github.com/gin-gonic/gin"
@@ -17,29 +21,47 @@ type Request struct {
 	Song  string `json:"song" validate:"required"`
 }
 
-type SongDetail struct {
-	ReleaseDate string `json:"release_date"`
-	Text        string `json:"text"`
-	Link        string `json:"link"`
+// Options are the query parameters of the request
+type Options struct {
+	Async bool `form:"async"`
+}
+
+// Accepted is a song added with a pending enrichment and its queued job
+type Accepted struct {
+	postgres.Song
+	Job postgres.EnrichmentJob `json:"job"`
 }
 
 type SongAdder interface {
-	AddSong(song postgres.Song) error
+	AddSong(song postgres.Song, author string) (postgres.Song, error)
+	AddPendingSong(song postgres.Song, author string) (postgres.Song, postgres.EnrichmentJob, error)
+}
+
+type InfoGetter interface {
+	GetInfo(ctx context.Context, group, song string) (enrichment.SongDetail, error)
 }
 
 // New adds the song in database
-func New(log *logger.Logger, songAdder SongAdder) gin.HandlerFunc {
+func New(log *logger.Logger, songAdder SongAdder, infoGetter InfoGetter) gin.HandlerFunc {
 
 	/**
 	 * @BasePath /api/v1
 	 * @Summary Adds a new song
 	 * @Description Adds a new song to the database with the given attributes.
+	 * @Description With async the song is accepted at once with a pending enrichment status and its info
+	 * @Description is looked up in the background, see /api/v1/songs/{id}/enrichment.
 	 * @Tag Song
 	 * @OperationId addSong
 	 * @Param Request body required true "The song attributes to add"
+	 * @Param async query boolean false "Accept the song without waiting for its info"
 	 * @Success 200 {object} Song "The added song"
+	 * @Success 202 {object} Accepted "The accepted song and its enrichment job"
 	 * @Failure 400 {object} response "Bad request"
+	 * @Failure 404 {object} response "Song info not found"
 	 * @Failure 500 {object} response "Internal server error"
+	 * @Failure 502 {object} response "Music info API failed"
+	 * @Failure 503 {object} response "Music info API unavailable"
+	 * @Failure 504 {object} response "Music info API timed out"
 	 * @Router /api/v1/song/add [post]
 	 */
 	return func(c *gin.Context) {
@@ -59,25 +81,57 @@ func New(log *logger.Logger, songAdder SongAdder) gin.HandlerFunc {
 
 			return
 		}
+		var opts Options
+		if err := c.ShouldBindQuery(&opts); err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			log.LogError("error happened at handler",
+				zap.String("op:", op),
+				zap.Error(err))
+
+			return
+		}
 		log.LogInfo("got new request at", zap.String("op", op),
 			zap.String("group", req.Group),
-			zap.String("song", req.Song))
+			zap.String("song", req.Song),
+			zap.Bool("async", opts.Async))
 		song := postgres.Song{
 			Group: req.Group,
 			Song:  req.Song,
 		}
-		info, err := GetInfo(log, req.Group, req.Song)
+
+		if opts.Async {
+			added, job, err := songAdder.AddPendingSong(song, author.From(c))
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, response.Error(err))
+				log.LogError("error at handling at ", zap.String("op", op),
+					zap.Error(err))
+				return
+			}
+
+			c.Header("ETag", etag.Format(added.Version))
+			c.JSON(http.StatusAccepted, response.OK(Accepted{Song: added, Job: job}))
+			return
+		}
+
+		info, err := infoGetter.GetInfo(c.Request.Context(), req.Group, req.Song)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, response.Error(err))
+			c.JSON(infoErrorStatus(err), response.Error(err))
 			log.LogError("error at handling at ", zap.String("op", op),
 				zap.Error(err))
 			return
 		}
 		song.Link = info.Link
-		song.ReleaseDate = info.ReleaseDate
+		if info.ReleaseDate != "" {
+			song.ReleaseDate, err = releasedate.Parse(info.ReleaseDate)
+			if err != nil {
+				log.LogError("dropping the release date of the song", zap.String("op", op),
+					zap.Error(err))
+			}
+		}
 		song.Text = info.Text
+		song.Sources = info.Sources
 
-		err = songAdder.AddSong(song)
+		added, err := songAdder.AddSong(song, author.From(c))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.Error(err))
 			log.LogError("error at handling at ", zap.String("op", op),
@@ -85,45 +139,35 @@ func New(log *logger.Logger, songAdder SongAdder) gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusOK, response.OK(song))
+		c.Header("ETag", etag.Format(added.Version))
+		c.JSON(http.StatusOK, response.OK(added))
 	}
 }
 
-// GetInfo gets full information about the song from API
-func GetInfo(log *logger.Logger, group, song string) (SongDetail, error) {
-	const op = "handlers.song_add.GetInfo"
-
-	log.LogInfo("trying to get full info", zap.String("op", op),
-		zap.String("group", group),
-		zap.String("song", song))
-
-	client := &http.Client{}
-
-	url := "http://localhost:8080/info?group=" + group + "&song=" + song
-
-	resp, err := client.Get(url)
-	if err != nil {
-		return SongDetail{}, err
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return SongDetail{}, errors.New("internal error")
-	}
-
-	if resp.Body == nil {
-		return SongDetail{}, errors.New("response body is nil")
-	}
-
-	var info SongDetail
-	err = json.NewDecoder(resp.Body).Decode(&info)
-	if err != nil {
-		return SongDetail{}, err
+// infoErrorStatus maps enrichment errors to the response status code: the
+// song is unknown upstream, the upstream is unavailable or overloaded, it
+// timed out or it failed otherwise
+func infoErrorStatus(err error) int {
+	var status *enrichment.StatusError
+	switch {
+	case errors.Is(err, enrichment.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, enrichment.ErrCircuitOpen):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, enrichment.ErrTimeout):
+		return http.StatusGatewayTimeout
+	case errors.As(err, &status):
+		switch status.Code {
+		case http.StatusServiceUnavailable, http.StatusTooManyRequests:
+			return http.StatusServiceUnavailable
+		case http.StatusGatewayTimeout:
+			return http.StatusGatewayTimeout
+		default:
+			return http.StatusBadGateway
+		}
+	case errors.Is(err, context.Canceled):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadGateway
 	}
-
-	log.LogDebug("got the full information", zap.String("op", op),
-		zap.String("group", group),
-		zap.String("song", song))
-
-	return info, nil
-
 }
@@ -0,0 +1,242 @@
+package annotation
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/foreground-eclipse/song-library/internal/lib/anchor"
+	"github.com/foreground-eclipse/song-library/internal/lib/api/author"
+	"github.com/foreground-eclipse/song-library/internal/lib/api/response"
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type Request struct {
+	Line  int    `json:"line" validate:"required"`
+	Start int    `json:"start,omitempty"`
+	End   int    `json:"end,omitempty"`
+	Body  string `json:"body" validate:"required"`
+}
+
+// PatchRequest changes the body of an annotation or moves it to another
+// range when the line is given
+type PatchRequest struct {
+	Line  *int    `json:"line,omitempty"`
+	Start int     `json:"start,omitempty"`
+	End   int     `json:"end,omitempty"`
+	Body  *string `json:"body,omitempty"`
+}
+
+type AnnotationAdder interface {
+	AddAnnotation(a postgres.Annotation, r postgres.Range) (postgres.Annotation, error)
+}
+
+type AnnotationLister interface {
+	ListAnnotations(songID string) ([]postgres.Annotation, error)
+}
+
+type AnnotationUpdater interface {
+	UpdateAnnotation(songID, id string, patch postgres.AnnotationPatch) (postgres.Annotation, error)
+}
+
+type AnnotationDeleter interface {
+	DeleteAnnotation(songID, id string) error
+}
+
+/**
+ * NewAdd attaches an annotation to a range of the lyrics of the song
+ * NewAdd godoc
+ * @Summary Adds an annotation
+ * @Tags annotation
+ * @Description Annotates a range of characters of a line of the lyrics, lines are numbered from 1.
+ * @Description Without start and end the annotation covers the whole line.
+ * @Param id path string true "The id of the song"
+ * @Param X-User header string false "The author of the annotation"
+ * @Param request body Request true "The annotated range and the annotation"
+ * @Success 201 {object} Annotation "The added annotation"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Song not found"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/songs/{id}/annotations [post]
+ */
+func NewAdd(log *logger.Logger, adder AnnotationAdder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.annotation.NewAdd"
+
+		var req Request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			log.LogError("error happened at handler",
+				zap.String("op:", op),
+				zap.Error(err))
+
+			return
+		}
+		if req.Line == 0 || req.Body == "" {
+			c.JSON(http.StatusBadRequest, response.Error(errors.New("missing required fields")))
+			return
+		}
+
+		id := c.Param("id")
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("id", id),
+			zap.Int("line", req.Line))
+
+		added, err := adder.AddAnnotation(postgres.Annotation{
+			SongID: id,
+			Body:   req.Body,
+			Author: author.From(c),
+		}, postgres.Range{Line: req.Line, Start: req.Start, End: req.End})
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error adding the annotation at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.JSON(http.StatusCreated, response.OK(added))
+	}
+}
+
+/**
+ * NewList returns the annotations of the song
+ * NewList godoc
+ * @Summary Lists annotations
+ * @Tags annotation
+ * @Description Lists the annotations of the song in the order of the lyrics, orphaned annotations
+ * @Description whose text was removed by an edit come last.
+ * @Param id path string true "The id of the song"
+ * @Success 200 {object} response "The annotations"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Song not found"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/songs/{id}/annotations [get]
+ */
+func NewList(log *logger.Logger, lister AnnotationLister) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.annotation.NewList"
+
+		id := c.Param("id")
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("id", id))
+
+		annotations, err := lister.ListAnnotations(id)
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error listing the annotations at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.JSON(http.StatusOK, response.OK(annotations))
+	}
+}
+
+/**
+ * NewUpdate edits an annotation of the song
+ * NewUpdate godoc
+ * @Summary Edits an annotation
+ * @Tags annotation
+ * @Description Changes the body of the annotation or moves it to another range when the line is given.
+ * @Param id path string true "The id of the song"
+ * @Param annotation_id path string true "The id of the annotation"
+ * @Param request body PatchRequest true "The attributes to change"
+ * @Success 200 {object} Annotation "The edited annotation"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Song or annotation not found"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/songs/{id}/annotations/{annotation_id} [patch]
+ */
+func NewUpdate(log *logger.Logger, updater AnnotationUpdater) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.annotation.NewUpdate"
+
+		var req PatchRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			log.LogError("error happened at handler",
+				zap.String("op:", op),
+				zap.Error(err))
+
+			return
+		}
+		if req.Body != nil && *req.Body == "" {
+			c.JSON(http.StatusBadRequest, response.Error(errors.New("body must not be empty")))
+			return
+		}
+
+		var patch postgres.AnnotationPatch
+		patch.Body = req.Body
+		if req.Line != nil {
+			patch.Range = &postgres.Range{Line: *req.Line, Start: req.Start, End: req.End}
+		}
+
+		id := c.Param("id")
+		annotationID := c.Param("annotation_id")
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("id", id),
+			zap.String("annotation_id", annotationID))
+
+		updated, err := updater.UpdateAnnotation(id, annotationID, patch)
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error updating the annotation at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.JSON(http.StatusOK, response.OK(updated))
+	}
+}
+
+/**
+ * NewDelete deletes an annotation of the song
+ * NewDelete godoc
+ * @Summary Deletes an annotation
+ * @Tags annotation
+ * @Param id path string true "The id of the song"
+ * @Param annotation_id path string true "The id of the annotation"
+ * @Success 200 {object} response "Annotation deleted successfully"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Annotation not found"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/songs/{id}/annotations/{annotation_id} [delete]
+ */
+func NewDelete(log *logger.Logger, deleter AnnotationDeleter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.annotation.NewDelete"
+
+		id := c.Param("id")
+		annotationID := c.Param("annotation_id")
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("id", id),
+			zap.String("annotation_id", annotationID))
+
+		if err := deleter.DeleteAnnotation(id, annotationID); err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error deleting the annotation at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.JSON(http.StatusOK, response.OK(nil))
+	}
+}
+
+// errorStatus maps storage errors to the response status code
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, postgres.ErrInvalidID), errors.Is(err, anchor.ErrInvalidRange):
+		return http.StatusBadRequest
+	case errors.Is(err, postgres.ErrNotFound), errors.Is(err, postgres.ErrAnnotationNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,110 @@
+package concordance
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/foreground-eclipse/song-library/internal/lib/api/response"
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	defaultContext  = 5
+	maxContext      = 30
+)
+
+type Request struct {
+	Query    string `form:"q" validate:"required"`
+	Context  *int   `form:"context"`
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size"`
+	Clean    bool   `form:"clean"`
+}
+
+type Concordancer interface {
+	Concordance(phrase string, opts postgres.ConcordanceOptions) ([]postgres.Occurrence, int, error)
+}
+
+/**
+ * New returns the occurrences of a word or phrase across the lyrics of all songs
+ * New godoc
+ * @Summary Keyword in context
+ * @Tags song
+ * @Description Finds every occurrence of the word or phrase in the lyrics with the words around it,
+ * @Description the group and name of the song and the number of the verse.
+ * @Param q query string true "The word or phrase"
+ * @Param context query integer false "The number of words on each side of an occurrence, 5 by default and up to 30"
+ * @Param page query integer false "The page number, starting from 1"
+ * @Param page_size query integer false "The number of occurrences on a page, up to 100"
+ * @Param clean query boolean false "Leave songs with explicit lyrics out"
+ * @Success 200 {object} response "The page of occurrences"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/concordance [get]
+ */
+func New(log *logger.Logger, concordancer Concordancer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.concordance.New"
+
+		var req Request
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			log.LogError("error happened at handler",
+				zap.String("op:", op),
+				zap.Error(err))
+
+			return
+		}
+
+		if req.Page == 0 {
+			req.Page = 1
+		}
+		if req.PageSize == 0 {
+			req.PageSize = defaultPageSize
+		}
+		if req.Page < 0 || req.PageSize < 0 || req.PageSize > maxPageSize {
+			c.JSON(http.StatusBadRequest, response.Error(errors.New("invalid page or page_size")))
+			return
+		}
+		context := defaultContext
+		if req.Context != nil {
+			context = *req.Context
+		}
+		if context < 0 || context > maxContext {
+			c.JSON(http.StatusBadRequest, response.Error(errors.New("invalid context")))
+			return
+		}
+
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("q", req.Query),
+			zap.Int("context", context),
+			zap.Int("page", req.Page),
+			zap.Int("page_size", req.PageSize),
+			zap.Bool("clean", req.Clean))
+
+		occurrences, total, err := concordancer.Concordance(req.Query, postgres.ConcordanceOptions{
+			Page:     req.Page,
+			PageSize: req.PageSize,
+			Context:  context,
+			Clean:    req.Clean,
+		})
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, postgres.ErrEmptyQuery) || errors.Is(err, postgres.ErrInvalidPage) {
+				status = http.StatusBadRequest
+			}
+			c.JSON(status, response.Error(err))
+			log.LogError("error building the concordance at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.JSON(http.StatusOK, response.OKPage(occurrences, req.Page, req.PageSize, total))
+	}
+}
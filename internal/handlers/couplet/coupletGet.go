@@ -1,9 +1,14 @@
 package couplet
 
 import (
+	"errors"
 	"net/http"
 
+	"github.com/foreground-eclipse/song-library/internal/handlers/variant"
+	"github.com/foreground-eclipse/song-library/internal/lib/api/langneg"
 	"github.com/foreground-eclipse/song-library/internal/lib/api/response"
+	"github.com/foreground-eclipse/song-library/internal/lib/releasedate"
+	"github.com/foreground-eclipse/song-library/internal/lib/rhyme"
 	"github.com/foreground-eclipse/song-library/internal/logger"
 	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
 	"github.com/gin-gonic/gin"
@@ -17,10 +22,41 @@ type Request struct {
 	Text        string `json:"text,omitempty"`
 	Link        string `json:"link,omitempty"`
 	Page        int    `json:"page,omitempty"`
+	Size        int    `json:"size,omitempty"`
+}
+
+// Options are the query parameters of the request
+type Options struct {
+	Match     string  `form:"match"`
+	Threshold float64 `form:"threshold"`
+	Rhymes    bool    `form:"rhymes"`
+	Clean     bool    `form:"clean"`
+}
+
+const maxSize = 50
+
+// FuzzyCouplet is a page of verses of the song found by fuzzy match
+type FuzzyCouplet struct {
+	Group      string   `json:"group"`
+	Song       string   `json:"song"`
+	Verses     []string `json:"verses"`
+	Similarity float64  `json:"similarity"`
+
+	Rhymes []rhyme.Verse `json:"rhymes,omitempty"`
+}
+
+// RhymedCouplet is a page of verses with their rhyme schemes
+type RhymedCouplet struct {
+	Verses []string      `json:"verses"`
+	Rhymes []rhyme.Verse `json:"rhymes"`
 }
 
 type CoupletGetter interface {
-	GetCouplet(filter postgres.Song, page int) (string, error)
+	GetCouplet(filter postgres.Song, page, size int) ([]string, int, error)
+	FindSong(filter postgres.Song, threshold float64) (postgres.ScoredSong, error)
+	GetSongs(filter postgres.Song, page int) (postgres.Song, error)
+	MaskExplicit(text string) string
+	variant.VariantLister
 }
 
 /**
@@ -28,13 +64,21 @@ type CoupletGetter interface {
  * New godoc
  * @Summary Gets a couplet for a song
  * @Tags couplet
- * @Description Gets a couplet for a song from the database.
+ * @Description Gets a page of verses of the song lyrics, verses are separated by blank lines.
  * @Param group query string true "The group of the song"
  * @Param song query string true "The name of the song"
- * @Param page query integer false "The page number of the couplet"
+ * @Param page query integer false "The page number of the couplet, starting from 1"
+ * @Param size query integer false "The number of verses on a page, 1 by default"
+ * @Param rhymes query boolean false "Return the rhyme schemes of the verses alongside them"
+ * @Param clean query boolean false "Mask the offending words of explicit lyrics"
+ * @Param match query string false "The group and song matching mode: exact (default) or fuzzy"
+ * @Param threshold query number false "The least similarity of a fuzzy match, 0.3 by default"
+ * @Param lang query string false "The language of the lyrics, overrides the Accept-Language header"
+ * @Param kind query string false "The lyrics variant kind: translation (default), transliteration or original"
  * @Param request body Request true "Request body"
- * @Success 200 {string} "The couplet for the song"
+ * @Success 200 {object} response "The verses with the total verse count"
  * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Song or page not found"
  * @Failure 500 {object} response "Internal server error"
  * @Router /api/v1/song/couplet [get]
  */
@@ -43,11 +87,6 @@ func New(log *logger.Logger, coupletGetter CoupletGetter) gin.HandlerFunc {
 		const op = "handlers.couplet_get.New"
 
 		var req Request
-
-		if req.Page < 0 {
-			req.Page = 0
-		}
-
 		log.LogDebug("received request", zap.String("op", op),
 			zap.String("group", req.Group),
 			zap.String("song", req.Song),
@@ -63,10 +102,41 @@ func New(log *logger.Logger, coupletGetter CoupletGetter) gin.HandlerFunc {
 
 			return
 		}
+		var opts Options
+		if err := c.ShouldBindQuery(&opts); err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			log.LogError("error happened at handler",
+				zap.String("op:", op),
+				zap.Error(err))
+
+			return
+		}
+
+		if req.Page == 0 {
+			req.Page = 1
+		}
+		if req.Size == 0 {
+			req.Size = 1
+		}
+		if req.Page < 0 || req.Size < 0 || req.Size > maxSize {
+			c.JSON(http.StatusBadRequest, response.Error(errors.New("invalid page or size")))
+			return
+		}
 
 		log.LogInfo("got new request at", zap.String("op", op),
 			zap.String("group", req.Group),
-			zap.String("song", req.Song))
+			zap.String("song", req.Song),
+			zap.Int("page", req.Page),
+			zap.Int("size", req.Size))
+
+		if req.ReleaseDate != "" {
+			date, err := releasedate.Parse(req.ReleaseDate)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, response.Error(err))
+				return
+			}
+			req.ReleaseDate = date
+		}
 
 		var filter postgres.Song
 		filter.Group = req.Group
@@ -74,16 +144,154 @@ func New(log *logger.Logger, coupletGetter CoupletGetter) gin.HandlerFunc {
 		filter.ReleaseDate = req.ReleaseDate
 		filter.Text = req.Text
 		filter.Link = req.Link
-		couplet, err := coupletGetter.GetCouplet(filter, req.Page)
 
+		match, err := postgres.ParseMatch(opts.Match)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, response.Error(err))
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			return
+		}
+
+		if match == postgres.MatchFuzzy {
+			found, err := coupletGetter.FindSong(filter, opts.Threshold)
+			if err != nil {
+				c.JSON(errorStatus(err), response.Error(err))
+				log.LogError("error finding the song at ", zap.String("op", op),
+					zap.Error(err))
+
+				return
+			}
+
+			verses, total, err := localizedCouplet(c, coupletGetter, found.Song, req.Page, req.Size)
+			if err != nil {
+				c.JSON(errorStatus(err), response.Error(err))
+				log.LogError("error getting the song details at ", zap.String("op", op),
+					zap.Error(err))
+
+				return
+			}
+			// rhymes are detected by the words as sung, only the text is masked
+			rhymes := schemes(opts, req, verses)
+			if opts.Clean {
+				verses = mask(coupletGetter, verses)
+				maskSchemes(coupletGetter, rhymes)
+			}
+
+			c.JSON(http.StatusOK, response.OKPage(FuzzyCouplet{
+				Group:      found.Group,
+				Song:       found.Song.Song,
+				Verses:     verses,
+				Similarity: found.Similarity,
+				Rhymes:     rhymes,
+			}, req.Page, req.Size, total))
+			return
+		}
+
+		if c.Query("lang") != "" || c.GetHeader("Accept-Language") != "" {
+			song, err := coupletGetter.GetSongs(filter, 1)
+			if err != nil {
+				c.JSON(errorStatus(err), response.Error(err))
+				log.LogError("error getting the song details at ", zap.String("op", op),
+					zap.Error(err))
+
+				return
+			}
+			if song.ID == "" {
+				c.JSON(http.StatusNotFound, response.Error(postgres.ErrNotFound))
+				return
+			}
+			filter = song
+		}
+
+		verses, total, err := localizedCouplet(c, coupletGetter, filter, req.Page, req.Size)
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
 			log.LogError("error getting the song details at ", zap.String("op", op),
 				zap.Error(err))
 
 			return
 		}
+		rhymes := schemes(opts, req, verses)
+		if opts.Clean {
+			verses = mask(coupletGetter, verses)
+			maskSchemes(coupletGetter, rhymes)
+		}
+
+		if opts.Rhymes {
+			c.JSON(http.StatusOK, response.OKPage(RhymedCouplet{
+				Verses: verses,
+				Rhymes: rhymes,
+			}, req.Page, req.Size, total))
+			return
+		}
+
+		c.JSON(http.StatusOK, response.OKPage(verses, req.Page, req.Size, total))
+	}
+}
+
+// mask masks the offending words of the verses
+func mask(getter CoupletGetter, verses []string) []string {
+	masked := make([]string, len(verses))
+	for i, verse := range verses {
+		masked[i] = getter.MaskExplicit(verse)
+	}
+	return masked
+}
+
+// maskSchemes masks the offending words of the lines of the rhyme schemes
+func maskSchemes(getter CoupletGetter, schemes []rhyme.Verse) {
+	for _, v := range schemes {
+		for i := range v.Lines {
+			v.Lines[i].Text = getter.MaskExplicit(v.Lines[i].Text)
+		}
+	}
+}
+
+// schemes returns the rhyme schemes of the page of verses when the request
+// asks for them, verses are numbered across the whole lyrics
+func schemes(opts Options, req Request, verses []string) []rhyme.Verse {
+	if !opts.Rhymes {
+		return nil
+	}
+
+	first := (req.Page-1)*req.Size + 1
+	schemes := make([]rhyme.Verse, 0, len(verses))
+	for i, verse := range verses {
+		schemes = append(schemes, rhyme.Scheme(first+i, verse))
+	}
+	return schemes
+}
+
+// localizedCouplet gets a page of verses of the song in the language
+// negotiated with the client, the song must be identified by its id when the
+// client asks for a language
+func localizedCouplet(c *gin.Context, getter CoupletGetter, song postgres.Song, page, size int) ([]string, int, error) {
+	if song.ID != "" {
+		v, ok, err := variant.Negotiate(c, getter, song)
+		if err != nil {
+			return nil, 0, err
+		}
+		if ok {
+			return postgres.VersePage(v.Text, page, size)
+		}
+		song = postgres.Song{ID: song.ID}
+	}
+
+	return getter.GetCouplet(song, page, size)
+}
 
-		c.JSON(http.StatusOK, response.OK(couplet))
+// errorStatus maps storage errors to the response status code
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, postgres.ErrInvalidPage),
+		errors.Is(err, postgres.ErrInvalidThreshold),
+		errors.Is(err, postgres.ErrInvalidKind),
+		errors.Is(err, langneg.ErrInvalidTag):
+		return http.StatusBadRequest
+	case errors.Is(err, postgres.ErrNotFound),
+		errors.Is(err, postgres.ErrPageOutOfRange),
+		errors.Is(err, langneg.ErrNoMatch):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
 	}
 }
@@ -0,0 +1,109 @@
+package couplet
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// stubGetter serves the verses of a single song
+type stubGetter struct {
+	verses []string
+}
+
+func (g stubGetter) GetCouplet(postgres.Song, int, int) ([]string, int, error) {
+	return g.verses, len(g.verses), nil
+}
+
+func (g stubGetter) FindSong(postgres.Song, float64) (postgres.ScoredSong, error) {
+	return postgres.ScoredSong{}, postgres.ErrNotFound
+}
+
+func (g stubGetter) GetSongs(postgres.Song, int) (postgres.Song, error) {
+	return postgres.Song{}, postgres.ErrNotFound
+}
+
+func (g stubGetter) MaskExplicit(text string) string {
+	return strings.ReplaceAll(text, "fuck", "f***")
+}
+
+func (g stubGetter) ListVariants(string) ([]postgres.Variant, error) {
+	return nil, nil
+}
+
+func TestNewClean(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	getter := stubGetter{verses: []string{"Out of luck\nwhat the fuck"}}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantVerses []string
+		wantScheme string
+		wantLines  []string
+	}{
+		{
+			name:       "rhymes of the words as sung",
+			query:      "?rhymes=true",
+			wantVerses: []string{"Out of luck\nwhat the fuck"},
+			wantScheme: "AA",
+			wantLines:  []string{"Out of luck", "what the fuck"},
+		},
+		{
+			name:       "clean text keeps the rhymes",
+			query:      "?rhymes=true&clean=true",
+			wantVerses: []string{"Out of luck\nwhat the f***"},
+			wantScheme: "AA",
+			wantLines:  []string{"Out of luck", "what the f***"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/couplet", New(&logger.Logger{Logger: zap.NewNop()}, getter))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/couplet"+tt.query,
+				strings.NewReader(`{"group": "Muse", "song": "Uprising"}`))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+			}
+			var resp struct {
+				Data RhymedCouplet `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(resp.Data.Verses, tt.wantVerses) {
+				t.Errorf("verses = %q, want %q", resp.Data.Verses, tt.wantVerses)
+			}
+			if len(resp.Data.Rhymes) != 1 {
+				t.Fatalf("rhymes = %+v, want one verse", resp.Data.Rhymes)
+			}
+			scheme := resp.Data.Rhymes[0]
+			if scheme.Scheme != tt.wantScheme {
+				t.Errorf("scheme = %q, want %q", scheme.Scheme, tt.wantScheme)
+			}
+			lines := make([]string, 0, len(scheme.Lines))
+			for _, l := range scheme.Lines {
+				lines = append(lines, l.Text)
+			}
+			if !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("scheme lines = %q, want %q", lines, tt.wantLines)
+			}
+		})
+	}
+}
@@ -1,10 +1,14 @@
 package songdelete
 
 import (
+	"errors"
 	"net/http"
 
+	"github.com/foreground-eclipse/song-library/internal/lib/api/author"
+	"github.com/foreground-eclipse/song-library/internal/lib/api/etag"
 	"github.com/foreground-eclipse/song-library/internal/lib/api/response"
 	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -15,7 +19,7 @@ type Request struct {
 }
 
 type SongDeleter interface {
-	DeleteSong(group, song string) error
+	DeleteSong(group, song string, change postgres.Change) error
 }
 
 // New deletes the song with given group and song
@@ -25,7 +29,10 @@ func New(log *logger.Logger, songDeleter SongDeleter) gin.HandlerFunc {
 	 * @Description Deletes a song from the database by its group and name.
 	 * @Param Request body required true "The song attributes to delete"
 	 * @Success 200 {object} response "Song deleted successfully"
+	 * @Param If-Match header string false "The ETag of the song version being deleted"
 	 * @Failure 400 {object} response "Bad request"
+	 * @Failure 404 {object} response "Song not found"
+	 * @Failure 412 {object} response "Song was changed since it was read"
 	 * @Failure 500 {object} response "Internal server error"
 	 * @Router /song [delete]
 	 */
@@ -49,15 +56,82 @@ func New(log *logger.Logger, songDeleter SongDeleter) gin.HandlerFunc {
 			zap.String("group", req.Group),
 			zap.String("song", req.Song))
 
-		err := songDeleter.DeleteSong(req.Group, req.Song)
+		version, err := etag.ParseIfMatch(c.GetHeader("If-Match"))
 		if err != nil {
-			c.JSON(http.StatusBadRequest, response.Error(err))
+			c.JSON(errorStatus(err), response.Error(err))
+			return
+		}
+
+		err = songDeleter.DeleteSong(req.Group, req.Song, postgres.Change{Version: version, Author: author.From(c)})
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error deleting the song at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+		c.JSON(http.StatusOK, response.OK(nil))
+
+	}
+}
+
+type SongByIDDeleter interface {
+	DeleteSongByID(id string, change postgres.Change) error
+}
+
+/**
+ * NewByID deletes the song with given id
+ * NewByID godoc
+ * @Summary Deletes a song by id
+ * @Tags song
+ * @Description Deletes a song from the database by its stable id.
+ * @Param id path string true "The id of the song"
+ * @Param If-Match header string false "The ETag of the song version being deleted"
+ * @Success 200 {object} response "Song deleted successfully"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Song not found"
+ * @Failure 412 {object} response "Song was changed since it was read"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/songs/{id} [delete]
+ */
+func NewByID(log *logger.Logger, songDeleter SongByIDDeleter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.song_delete.NewByID"
+
+		id := c.Param("id")
+		version, err := etag.ParseIfMatch(c.GetHeader("If-Match"))
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			return
+		}
+
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("id", id))
+
+		err = songDeleter.DeleteSongByID(id, postgres.Change{Version: version, Author: author.From(c)})
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
 			log.LogError("error deleting the song at ", zap.String("op", op),
 				zap.Error(err))
 
 			return
 		}
 		c.JSON(http.StatusOK, response.OK(nil))
+	}
+}
 
+// errorStatus maps storage errors to the response status code
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, postgres.ErrInvalidID):
+		return http.StatusBadRequest
+	case errors.Is(err, postgres.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, etag.ErrMultipleETags):
+		return http.StatusBadRequest
+	case errors.Is(err, etag.ErrInvalidETag), errors.Is(err, postgres.ErrVersionConflict):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
 	}
 }
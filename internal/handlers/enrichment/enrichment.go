@@ -0,0 +1,88 @@
+package songenrichment
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/foreground-eclipse/song-library/internal/enrichment"
+	"github.com/foreground-eclipse/song-library/internal/lib/api/response"
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type StatusGetter interface {
+	GetEnrichmentStatus(songID string) (postgres.EnrichmentStatus, error)
+}
+
+type CacheStatser interface {
+	Stats() enrichment.CacheStats
+}
+
+/**
+ * New returns the enrichment status of the song with its latest job
+ * New godoc
+ * @Summary Gets the enrichment status of a song
+ * @Tags enrichment
+ * @Description Gets whether the info of the song is pending, done or failed and its latest enrichment job
+ * @Description with the number of attempts, the last error and the time of the next attempt.
+ * @Description Songs enriched when added have no job.
+ * @Param id path string true "The id of the song"
+ * @Success 200 {object} EnrichmentStatus "The enrichment status"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Song not found"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/songs/{id}/enrichment [get]
+ */
+func New(log *logger.Logger, getter StatusGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.enrichment.New"
+
+		id := c.Param("id")
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("id", id))
+
+		status, err := getter.GetEnrichmentStatus(id)
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error getting the enrichment status at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.JSON(http.StatusOK, response.OK(status))
+	}
+}
+
+/**
+ * NewCacheStats returns the counters of the cache of the music info API
+ * NewCacheStats godoc
+ * @Summary Gets enrichment cache statistics
+ * @Tags enrichment
+ * @Description Gets the number of cached entries and the hits of the memory and the persistent tiers,
+ * @Description the hits for songs unknown to the music info API, the misses and the evictions since the start.
+ * @Success 200 {object} CacheStats "The cache statistics"
+ * @Router /api/v1/enrichment/cache/stats [get]
+ */
+func NewCacheStats(log *logger.Logger, statser CacheStatser) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.enrichment.NewCacheStats"
+
+		log.LogInfo("got new request at", zap.String("op", op))
+
+		c.JSON(http.StatusOK, response.OK(statser.Stats()))
+	}
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, postgres.ErrInvalidID):
+		return http.StatusBadRequest
+	case errors.Is(err, postgres.ErrNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
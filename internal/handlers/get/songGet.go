@@ -1,9 +1,14 @@
 package songget
 
 import (
+	"errors"
 	"net/http"
 
+	"github.com/foreground-eclipse/song-library/internal/handlers/variant"
+	"github.com/foreground-eclipse/song-library/internal/lib/api/etag"
+	"github.com/foreground-eclipse/song-library/internal/lib/api/langneg"
 	"github.com/foreground-eclipse/song-library/internal/lib/api/response"
+	"github.com/foreground-eclipse/song-library/internal/lib/releasedate"
 	"github.com/foreground-eclipse/song-library/internal/logger"
 	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
 	"github.com/gin-gonic/gin"
@@ -11,16 +16,20 @@ import (
 )
 
 type Request struct {
-	Group       string `json:"group" validate:"required"`
-	Song        string `json:"song" validate:"required"`
-	Page        int    `json:"page" validate:"required"`
-	ReleaseDate string `json:"release_date,omitempty"`
-	Text        string `json:"text,omitempty"`
-	Link        string `json:"link,omitempty"`
+	Group       string  `json:"group" validate:"required"`
+	Song        string  `json:"song" validate:"required"`
+	Page        int     `json:"page" validate:"required"`
+	ReleaseDate string  `json:"release_date,omitempty"`
+	Text        string  `json:"text,omitempty"`
+	Link        string  `json:"link,omitempty"`
+	Match       string  `json:"match,omitempty"`
+	Threshold   float64 `json:"threshold,omitempty"`
 }
 
 type SongGetter interface {
 	GetSongs(filter postgres.Song, page int) (postgres.Song, error)
+	FuzzySongs(filter postgres.Song, opts postgres.FuzzyOptions) ([]postgres.ScoredSong, int, error)
+	variant.VariantLister
 }
 
 // New is a handler for getting all songs with given filter
@@ -31,6 +40,7 @@ func New(log *logger.Logger, songGetter SongGetter) gin.HandlerFunc {
 	 * @Param Request body required true "The song attributes to search"
 	 * @Success 200 {object} Song "The found song"
 	 * @Failure 400 {object} response "Bad request"
+	 * @Failure 404 {object} response "No song matches the fuzzy lookup"
 	 * @Failure 500 {object} response "Internal server error"
 	 * @Router /song [get]
 	 */
@@ -55,6 +65,15 @@ func New(log *logger.Logger, songGetter SongGetter) gin.HandlerFunc {
 			zap.String("group", req.Group),
 			zap.String("song", req.Song),
 			zap.Int("page", req.Page))
+		if req.ReleaseDate != "" {
+			date, err := releasedate.Parse(req.ReleaseDate)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, response.Error(err))
+				return
+			}
+			req.ReleaseDate = date
+		}
+
 		var filter postgres.Song
 		filter.Group = req.Group
 		filter.Song = req.Song
@@ -62,6 +81,41 @@ func New(log *logger.Logger, songGetter SongGetter) gin.HandlerFunc {
 		filter.Text = req.Text
 		filter.Link = req.Link
 
+		match, err := postgres.ParseMatch(req.Match)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			return
+		}
+
+		if match == postgres.MatchFuzzy {
+			opts := postgres.FuzzyOptions{
+				Page:      req.Page,
+				PageSize:  1,
+				Threshold: req.Threshold,
+			}
+			songs, _, err := songGetter.FuzzySongs(filter, opts)
+			if err == nil && len(songs) == 0 {
+				err = postgres.ErrNotFound
+			}
+			if err != nil {
+				c.JSON(errorStatus(err), response.Error(err))
+				log.LogError("error getting the song details at ", zap.String("op", op),
+					zap.Error(err))
+
+				return
+			}
+
+			song := songs[0]
+			tag, err := localize(c, songGetter, &song.Song)
+			if err != nil {
+				c.JSON(errorStatus(err), response.Error(err))
+				return
+			}
+			c.Header("ETag", tag)
+			c.JSON(http.StatusOK, response.OK(song))
+			return
+		}
+
 		song, err := songGetter.GetSongs(filter, req.Page)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, response.Error(err))
@@ -72,6 +126,96 @@ func New(log *logger.Logger, songGetter SongGetter) gin.HandlerFunc {
 			return
 		}
 
+		if song.ID != "" {
+			tag, err := localize(c, songGetter, &song)
+			if err != nil {
+				c.JSON(errorStatus(err), response.Error(err))
+				return
+			}
+			c.Header("ETag", tag)
+		}
+		c.JSON(http.StatusOK, response.OK(song))
+	}
+}
+
+type SongByIDGetter interface {
+	GetSongByID(id string) (postgres.Song, error)
+	variant.VariantLister
+}
+
+/**
+ * NewByID returns the song with given id
+ * NewByID godoc
+ * @Summary Gets a song by id
+ * @Tags song
+ * @Description Gets a song from the database by its stable id.
+ * @Param id path string true "The id of the song"
+ * @Param lang query string false "The language of the lyrics, overrides the Accept-Language header"
+ * @Param kind query string false "The lyrics variant kind: translation (default), transliteration or original"
+ * @Success 200 {object} Song "The found song"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Song not found"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/songs/{id} [get]
+ */
+func NewByID(log *logger.Logger, songGetter SongByIDGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.song_get.NewByID"
+
+		id := c.Param("id")
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("id", id))
+
+		song, err := songGetter.GetSongByID(id)
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error getting the song details at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		tag, err := localize(c, songGetter, &song)
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error negotiating the lyrics language at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.Header("ETag", tag)
 		c.JSON(http.StatusOK, response.OK(song))
 	}
 }
+
+// localize replaces the lyrics of the song with the variant in the language
+// negotiated with the client and returns the entity tag of the result
+func localize(c *gin.Context, lister variant.VariantLister, song *postgres.Song) (string, error) {
+	v, ok, err := variant.Negotiate(c, lister, *song)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return etag.Format(song.Version), nil
+	}
+
+	song.Text = v.Text
+	return etag.FormatVariant(song.Version, v.Lang, v.Kind, v.UpdatedAt.UnixNano()), nil
+}
+
+// errorStatus maps storage errors to the response status code
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, postgres.ErrInvalidID),
+		errors.Is(err, postgres.ErrInvalidKind),
+		errors.Is(err, postgres.ErrInvalidPage),
+		errors.Is(err, postgres.ErrInvalidThreshold),
+		errors.Is(err, langneg.ErrInvalidTag):
+		return http.StatusBadRequest
+	case errors.Is(err, postgres.ErrNotFound), errors.Is(err, langneg.ErrNoMatch):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,275 @@
+package songlist
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/foreground-eclipse/song-library/internal/lib/api/langneg"
+	"github.com/foreground-eclipse/song-library/internal/lib/api/response"
+	"github.com/foreground-eclipse/song-library/internal/lib/releasedate"
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type Request struct {
+	Group       string  `form:"group"`
+	Song        string  `form:"song"`
+	ReleaseDate string  `form:"release_date"`
+	Link        string  `form:"link"`
+	Language    string  `form:"language"`
+	Page        int     `form:"page"`
+	PageSize    int     `form:"page_size"`
+	Sort        string  `form:"sort"`
+	Order       string  `form:"order"`
+	Paging      string  `form:"paging"`
+	Cursor      string  `form:"cursor"`
+	Match       string  `form:"match"`
+	Threshold   float64 `form:"threshold"`
+	Clean       bool    `form:"clean"`
+
+	ReleasedAfter  string `form:"released_after"`
+	ReleasedBefore string `form:"released_before"`
+	Year           int    `form:"year"`
+	Decade         string `form:"decade"`
+}
+
+type SongLister interface {
+	ListSongs(filter postgres.Song, opts postgres.ListOptions) ([]postgres.Song, int, error)
+	ListSongsByCursor(filter postgres.Song, opts postgres.CursorOptions) ([]postgres.Song, string, error)
+	FuzzySongs(filter postgres.Song, opts postgres.FuzzyOptions) ([]postgres.ScoredSong, int, error)
+}
+
+/**
+ * New returns a page of songs matching given attributes
+ * New godoc
+ * @Summary Lists songs
+ * @Tags song
+ * @Description Lists songs from the database with paging and sorting.
+ * @Param group query string false "The group of the song"
+ * @Param song query string false "The name of the song"
+ * @Param release_date query string false "The release date of the song"
+ * @Param released_after query string false "Only songs released after the date"
+ * @Param released_before query string false "Only songs released before the date"
+ * @Param year query integer false "Only songs released in the year"
+ * @Param decade query string false "Only songs released in the decade, e.g. 1990 or 90s"
+ * @Param link query string false "The link to the song"
+ * @Param language query string false "The detected language of the lyrics, e.g. en, or und when undetermined"
+ * @Param page query integer false "The page number, starting from 1"
+ * @Param page_size query integer false "The number of songs on a page, up to 100"
+ * @Param sort query string false "The sort field: group, song or release_date"
+ * @Param order query string false "The sort direction: asc or desc"
+ * @Param paging query string false "The paging mode: offset (default) or cursor"
+ * @Param cursor query string false "The cursor of the next page returned by a previous cursor listing with the same filters and sort"
+ * @Param match query string false "The group and song matching mode: exact (default) or fuzzy"
+ * @Param threshold query number false "The minimal similarity of a fuzzy match, 0.3 by default"
+ * @Param clean query boolean false "Leave songs with explicit lyrics out"
+ * @Success 200 {object} response "The page of songs"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/song/list [get]
+ */
+func New(log *logger.Logger, songLister SongLister) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.song_list.New"
+
+		var req Request
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			log.LogError("error happened at handler",
+				zap.String("op:", op),
+				zap.Error(err))
+
+			return
+		}
+
+		if req.Page == 0 {
+			req.Page = 1
+		}
+		if req.PageSize == 0 {
+			req.PageSize = defaultPageSize
+		}
+		if req.Page < 0 || req.PageSize < 0 || req.PageSize > maxPageSize {
+			c.JSON(http.StatusBadRequest, response.Error(errors.New("invalid page or page_size")))
+			return
+		}
+
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("group", req.Group),
+			zap.String("song", req.Song),
+			zap.Int("page", req.Page),
+			zap.Int("page_size", req.PageSize),
+			zap.String("sort", req.Sort),
+			zap.String("order", req.Order))
+
+		if req.ReleaseDate != "" {
+			date, err := releasedate.Parse(req.ReleaseDate)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, response.Error(err))
+				return
+			}
+			req.ReleaseDate = date
+		}
+
+		if req.Language != "" {
+			lang, err := langneg.Canonical(req.Language)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, response.Error(err))
+				return
+			}
+			req.Language = lang
+		}
+
+		released, err := releasedRange(req)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			return
+		}
+
+		filter := postgres.Song{
+			Group:       req.Group,
+			Song:        req.Song,
+			ReleaseDate: req.ReleaseDate,
+			Link:        req.Link,
+			Language:    req.Language,
+		}
+
+		match, err := postgres.ParseMatch(req.Match)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			return
+		}
+
+		if match == postgres.MatchFuzzy {
+			if req.Paging == "cursor" || req.Cursor != "" || req.Sort != "" {
+				c.JSON(http.StatusBadRequest, response.Error(errors.New("fuzzy match is ordered by similarity and paged by offset")))
+				return
+			}
+			opts := postgres.FuzzyOptions{
+				Page:      req.Page,
+				PageSize:  req.PageSize,
+				Threshold: req.Threshold,
+				Released:  released,
+				Clean:     req.Clean,
+			}
+
+			songs, total, err := songLister.FuzzySongs(filter, opts)
+			if err != nil {
+				c.JSON(listErrorStatus(err), response.Error(err))
+				log.LogError("error listing the songs at ", zap.String("op", op),
+					zap.Error(err))
+
+				return
+			}
+
+			c.JSON(http.StatusOK, response.OKPage(songs, req.Page, req.PageSize, total))
+			return
+		}
+
+		if req.Paging == "cursor" || req.Cursor != "" {
+			opts := postgres.CursorOptions{
+				Cursor:   req.Cursor,
+				PageSize: req.PageSize,
+				SortBy:   req.Sort,
+				Order:    req.Order,
+				Released: released,
+				Clean:    req.Clean,
+			}
+
+			songs, next, err := songLister.ListSongsByCursor(filter, opts)
+			if err != nil {
+				c.JSON(listErrorStatus(err), response.Error(err))
+				log.LogError("error listing the songs at ", zap.String("op", op),
+					zap.Error(err))
+
+				return
+			}
+
+			c.JSON(http.StatusOK, response.OKCursor(songs, req.PageSize, next))
+			return
+		}
+
+		opts := postgres.ListOptions{
+			Page:     req.Page,
+			PageSize: req.PageSize,
+			SortBy:   req.Sort,
+			Order:    req.Order,
+			Released: released,
+			Clean:    req.Clean,
+		}
+
+		songs, total, err := songLister.ListSongs(filter, opts)
+		if err != nil {
+			c.JSON(listErrorStatus(err), response.Error(err))
+			log.LogError("error listing the songs at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.JSON(http.StatusOK, response.OKPage(songs, req.Page, req.PageSize, total))
+	}
+}
+
+// releasedRange returns the range of release dates selected by the request
+func releasedRange(req Request) (postgres.Released, error) {
+	var r releasedate.Range
+
+	if req.ReleasedAfter != "" {
+		after, err := releasedate.ParseTime(req.ReleasedAfter)
+		if err != nil {
+			return postgres.Released{}, err
+		}
+		r = r.Intersect(releasedate.Range{From: after.AddDate(0, 0, 1)})
+	}
+	if req.ReleasedBefore != "" {
+		before, err := releasedate.ParseTime(req.ReleasedBefore)
+		if err != nil {
+			return postgres.Released{}, err
+		}
+		r = r.Intersect(releasedate.Range{Until: before})
+	}
+	if req.Year != 0 {
+		if req.Year < 1 || req.Year > 9999 {
+			return postgres.Released{}, fmt.Errorf("invalid year: %d", req.Year)
+		}
+		r = r.Intersect(releasedate.Year(req.Year))
+	}
+	if req.Decade != "" {
+		decade, err := releasedate.Decade(req.Decade)
+		if err != nil {
+			return postgres.Released{}, err
+		}
+		r = r.Intersect(decade)
+	}
+
+	var released postgres.Released
+	if !r.From.IsZero() {
+		released.From = r.From.Format(releasedate.ISO)
+	}
+	if !r.Until.IsZero() {
+		released.Until = r.Until.Format(releasedate.ISO)
+	}
+	return released, nil
+}
+
+// listErrorStatus maps storage errors to the response status code
+func listErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, postgres.ErrInvalidSort),
+		errors.Is(err, postgres.ErrInvalidOrder),
+		errors.Is(err, postgres.ErrInvalidPage),
+		errors.Is(err, postgres.ErrInvalidCursor),
+		errors.Is(err, postgres.ErrInvalidThreshold):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,64 @@
+package songlyrics
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/foreground-eclipse/song-library/internal/lib/api/response"
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type LyricsGetter interface {
+	GetLyrics(id string) (postgres.Lyrics, error)
+}
+
+/**
+ * New returns the structured lyrics of the song
+ * New godoc
+ * @Summary Gets structured lyrics
+ * @Tags lyrics
+ * @Description Gets the ordered sections of the song lyrics labeled intro, verse, chorus, bridge etc.
+ * @Description Repeated choruses refer to their first occurrence by repeat_of.
+ * @Description Annotations mark the annotated character ranges of the lines of the text, lines are numbered from 1.
+ * @Param id path string true "The id of the song"
+ * @Success 200 {object} Lyrics "The structured lyrics"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Song not found"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/songs/{id}/lyrics [get]
+ */
+func New(log *logger.Logger, lyricsGetter LyricsGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.lyrics_get.New"
+
+		id := c.Param("id")
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("id", id))
+
+		lyrics, err := lyricsGetter.GetLyrics(id)
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error getting the lyrics at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.JSON(http.StatusOK, response.OK(lyrics))
+	}
+}
+
+// errorStatus maps storage errors to the response status code
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, postgres.ErrInvalidID):
+		return http.StatusBadRequest
+	case errors.Is(err, postgres.ErrNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
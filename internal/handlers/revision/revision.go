@@ -0,0 +1,228 @@
+package revision
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/foreground-eclipse/song-library/internal/lib/api/author"
+	"github.com/foreground-eclipse/song-library/internal/lib/api/etag"
+	"github.com/foreground-eclipse/song-library/internal/lib/api/response"
+	"github.com/foreground-eclipse/song-library/internal/lib/diff"
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type RevisionLister interface {
+	ListRevisions(id string) ([]postgres.Revision, error)
+}
+
+type RevisionGetter interface {
+	GetRevision(id string, version int) (postgres.Revision, error)
+}
+
+type RevisionRestorer interface {
+	RestoreRevision(id string, version int, change postgres.Change) (postgres.Song, error)
+}
+
+type DiffRequest struct {
+	From int `form:"from" validate:"required"`
+	To   int `form:"to" validate:"required"`
+}
+
+// FieldChange is a change of a song attribute between two revisions
+type FieldChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Diff is the difference between two revisions of a song
+type Diff struct {
+	From   int                    `json:"from"`
+	To     int                    `json:"to"`
+	Fields map[string]FieldChange `json:"fields"`
+	Text   []diff.Line            `json:"text"`
+}
+
+/**
+ * NewList returns all the revisions of the song
+ * NewList godoc
+ * @Summary Lists song revisions
+ * @Tags revision
+ * @Description Lists every recorded state of the song with its author and time, latest first.
+ * @Param id path string true "The id of the song"
+ * @Success 200 {object} response "The revisions of the song"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Song not found"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/songs/{id}/revisions [get]
+ */
+func NewList(log *logger.Logger, revisionLister RevisionLister) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.revision.NewList"
+
+		id := c.Param("id")
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("id", id))
+
+		revisions, err := revisionLister.ListRevisions(id)
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error listing the revisions at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.JSON(http.StatusOK, response.OK(revisions))
+	}
+}
+
+/**
+ * NewDiff returns the difference between two revisions of the song
+ * NewDiff godoc
+ * @Summary Diffs song revisions
+ * @Tags revision
+ * @Description Compares two revisions of the song field by field, with a line diff of the lyrics.
+ * @Param id path string true "The id of the song"
+ * @Param from query integer true "The version of the older revision"
+ * @Param to query integer true "The version of the newer revision"
+ * @Success 200 {object} Diff "The difference between the revisions"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Revision not found"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/songs/{id}/revisions/diff [get]
+ */
+func NewDiff(log *logger.Logger, revisionGetter RevisionGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.revision.NewDiff"
+
+		id := c.Param("id")
+
+		var req DiffRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			log.LogError("error happened at handler",
+				zap.String("op:", op),
+				zap.Error(err))
+
+			return
+		}
+		if req.From < 1 || req.To < 1 {
+			c.JSON(http.StatusBadRequest, response.Error(errors.New("from and to must be revision versions")))
+			return
+		}
+
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("id", id),
+			zap.Int("from", req.From),
+			zap.Int("to", req.To))
+
+		from, err := revisionGetter.GetRevision(id, req.From)
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error getting the revision at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+		to, err := revisionGetter.GetRevision(id, req.To)
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error getting the revision at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		d := Diff{
+			From:   req.From,
+			To:     req.To,
+			Fields: make(map[string]FieldChange),
+			Text:   diff.Lines(from.Text, to.Text),
+		}
+		for _, f := range []struct {
+			name     string
+			from, to string
+		}{
+			{"group", from.Group, to.Group},
+			{"song", from.Song, to.Song},
+			{"release_date", from.ReleaseDate, to.ReleaseDate},
+			{"text", from.Text, to.Text},
+			{"link", from.Link, to.Link},
+		} {
+			if f.from != f.to {
+				d.Fields[f.name] = FieldChange{From: f.from, To: f.to}
+			}
+		}
+
+		c.JSON(http.StatusOK, response.OK(d))
+	}
+}
+
+/**
+ * NewRestore sets the song to the state of its revision
+ * NewRestore godoc
+ * @Summary Restores a song revision
+ * @Tags revision
+ * @Description Sets the attributes of the song to the ones of the given revision, bringing back deleted songs.
+ * @Param id path string true "The id of the song"
+ * @Param version path integer true "The version of the revision to restore"
+ * @Param If-Match header string false "The ETag of the current song version"
+ * @Success 200 {object} Song "The restored song"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Revision not found"
+ * @Failure 412 {object} response "Song was changed since it was read"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/songs/{id}/revisions/{version}/restore [post]
+ */
+func NewRestore(log *logger.Logger, revisionRestorer RevisionRestorer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.revision.NewRestore"
+
+		id := c.Param("id")
+		version, err := strconv.Atoi(c.Param("version"))
+		if err != nil || version < 1 {
+			c.JSON(http.StatusBadRequest, response.Error(errors.New("invalid revision version")))
+			return
+		}
+
+		current, err := etag.ParseIfMatch(c.GetHeader("If-Match"))
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			return
+		}
+
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("id", id),
+			zap.Int("version", version))
+
+		song, err := revisionRestorer.RestoreRevision(id, version, postgres.Change{Version: current, Author: author.From(c)})
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error restoring the revision at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.Header("ETag", etag.Format(song.Version))
+		c.JSON(http.StatusOK, response.OK(song))
+	}
+}
+
+// errorStatus maps storage errors to the response status code
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, postgres.ErrInvalidID), errors.Is(err, etag.ErrMultipleETags):
+		return http.StatusBadRequest
+	case errors.Is(err, postgres.ErrNotFound), errors.Is(err, postgres.ErrRevisionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, etag.ErrInvalidETag), errors.Is(err, postgres.ErrVersionConflict):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
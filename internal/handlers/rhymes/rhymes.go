@@ -0,0 +1,71 @@
+package songrhymes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/foreground-eclipse/song-library/internal/lib/api/response"
+	"github.com/foreground-eclipse/song-library/internal/lib/lyrics"
+	"github.com/foreground-eclipse/song-library/internal/lib/rhyme"
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type SongGetter interface {
+	GetSongByID(id string) (postgres.Song, error)
+}
+
+/**
+ * New returns the rhyme schemes of the verses of the song
+ * New godoc
+ * @Summary Gets rhyme schemes
+ * @Tags rhymes
+ * @Description Labels the lines of every verse of the lyrics with letters, rhyming lines share a letter,
+ * @Description e.g. AABB or ABAB. Rhymes are detected by the sound of the last word of a line.
+ * @Param id path string true "The id of the song"
+ * @Success 200 {object} response "The rhyme schemes of the verses"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Song not found"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/songs/{id}/rhymes [get]
+ */
+func New(log *logger.Logger, songGetter SongGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.rhymes.New"
+
+		id := c.Param("id")
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("id", id))
+
+		song, err := songGetter.GetSongByID(id)
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error getting the song details at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		verses := lyrics.Verses(song.Text)
+		schemes := make([]rhyme.Verse, 0, len(verses))
+		for i, verse := range verses {
+			schemes = append(schemes, rhyme.Scheme(i+1, verse))
+		}
+
+		c.JSON(http.StatusOK, response.OK(schemes))
+	}
+}
+
+// errorStatus maps storage errors to the response status code
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, postgres.ErrInvalidID):
+		return http.StatusBadRequest
+	case errors.Is(err, postgres.ErrNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,95 @@
+package songsearch
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/foreground-eclipse/song-library/internal/lib/api/response"
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type Request struct {
+	Query    string `form:"q" validate:"required"`
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size"`
+	Clean    bool   `form:"clean"`
+}
+
+type SongSearcher interface {
+	SearchSongs(query string, opts postgres.SearchOptions) ([]postgres.SearchResult, int, error)
+}
+
+/**
+ * New returns songs matching the full-text query
+ * New godoc
+ * @Summary Searches songs
+ * @Tags song
+ * @Description Ranked full-text search over lyrics, groups and song names with HTML-escaped snippets highlighting the matches in <mark> tags.
+ * @Param q query string true "The search query, e.g. a line of the chorus"
+ * @Param page query integer false "The page number, starting from 1"
+ * @Param page_size query integer false "The number of songs on a page, up to 100"
+ * @Param clean query boolean false "Leave songs with explicit lyrics out"
+ * @Success 200 {object} response "The page of found songs"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/song/search [get]
+ */
+func New(log *logger.Logger, songSearcher SongSearcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.song_search.New"
+
+		var req Request
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			log.LogError("error happened at handler",
+				zap.String("op:", op),
+				zap.Error(err))
+
+			return
+		}
+
+		if req.Page == 0 {
+			req.Page = 1
+		}
+		if req.PageSize == 0 {
+			req.PageSize = defaultPageSize
+		}
+		if req.Page < 0 || req.PageSize < 0 || req.PageSize > maxPageSize {
+			c.JSON(http.StatusBadRequest, response.Error(errors.New("invalid page or page_size")))
+			return
+		}
+
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("q", req.Query),
+			zap.Int("page", req.Page),
+			zap.Int("page_size", req.PageSize),
+			zap.Bool("clean", req.Clean))
+
+		results, total, err := songSearcher.SearchSongs(req.Query, postgres.SearchOptions{
+			Page:     req.Page,
+			PageSize: req.PageSize,
+			Clean:    req.Clean,
+		})
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, postgres.ErrEmptyQuery) || errors.Is(err, postgres.ErrInvalidPage) {
+				status = http.StatusBadRequest
+			}
+			c.JSON(status, response.Error(err))
+			log.LogError("error searching the songs at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.JSON(http.StatusOK, response.OKPage(results, req.Page, req.PageSize, total))
+	}
+}
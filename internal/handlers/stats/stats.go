@@ -0,0 +1,139 @@
+package songstats
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/foreground-eclipse/song-library/internal/lib/api/response"
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const defaultTop = 10
+
+type Request struct {
+	Top *int `form:"top"`
+}
+
+type SongStatsGetter interface {
+	GetSongStats(id string, top int) (postgres.SongStats, error)
+}
+
+type GroupStatsGetter interface {
+	GetGroupStats(group string, top int) (postgres.GroupStats, error)
+}
+
+/**
+ * NewSong returns the statistics of the lyrics of the song
+ * NewSong godoc
+ * @Summary Gets lyrics statistics of a song
+ * @Tags stats
+ * @Description Counts lines, verses, words and unique words of the lyrics, the most frequent words
+ * @Description except stop words of the song language, the share of repeated lines and the reading time in seconds.
+ * @Param id path string true "The id of the song"
+ * @Param top query integer false "The number of most frequent words, 10 by default and up to 50"
+ * @Success 200 {object} SongStats "The statistics"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Song not found"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/songs/{id}/stats [get]
+ */
+func NewSong(log *logger.Logger, getter SongStatsGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.stats.NewSong"
+
+		top, ok := bindTop(c)
+		if !ok {
+			return
+		}
+
+		id := c.Param("id")
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("id", id),
+			zap.Int("top", top))
+
+		stats, err := getter.GetSongStats(id, top)
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error getting the song stats at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.JSON(http.StatusOK, response.OK(stats))
+	}
+}
+
+/**
+ * NewGroup returns the statistics of the lyrics of all the songs of the group
+ * NewGroup godoc
+ * @Summary Gets lyrics statistics of a group
+ * @Tags stats
+ * @Description Computes the statistics of the lyrics of all the songs of the group taken together.
+ * @Param group path string true "The group"
+ * @Param top query integer false "The number of most frequent words, 10 by default and up to 50"
+ * @Success 200 {object} GroupStats "The statistics"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Group not found"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/groups/{group}/stats [get]
+ */
+func NewGroup(log *logger.Logger, getter GroupStatsGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.stats.NewGroup"
+
+		top, ok := bindTop(c)
+		if !ok {
+			return
+		}
+
+		group := c.Param("group")
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("group", group),
+			zap.Int("top", top))
+
+		stats, err := getter.GetGroupStats(group, top)
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error getting the group stats at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.JSON(http.StatusOK, response.OK(stats))
+	}
+}
+
+// bindTop returns the requested number of most frequent words, responding
+// with 400 when it is invalid
+func bindTop(c *gin.Context) (int, bool) {
+	var req Request
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(err))
+		return 0, false
+	}
+	if req.Top == nil {
+		return defaultTop, true
+	}
+	if *req.Top < 0 || *req.Top > postgres.MaxTopWords {
+		c.JSON(http.StatusBadRequest, response.Error(errors.New("invalid top")))
+		return 0, false
+	}
+	return *req.Top, true
+}
+
+// errorStatus maps storage errors to the response status code
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, postgres.ErrInvalidID), errors.Is(err, postgres.ErrInvalidPage):
+		return http.StatusBadRequest
+	case errors.Is(err, postgres.ErrNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
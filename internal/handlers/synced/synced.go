@@ -0,0 +1,264 @@
+package synced
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/foreground-eclipse/song-library/internal/lib/api/response"
+	"github.com/foreground-eclipse/song-library/internal/lib/lrc"
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	formatJSON = "json"
+	formatLRC  = "lrc"
+	formatVTT  = "vtt"
+
+	// maxLRCSize limits the size of imported LRC files
+	maxLRCSize = 1 << 20
+
+	// lastLineDuration is how long the last line is shown in WebVTT
+	lastLineDuration = 5 * time.Second
+)
+
+type TimedLinesSaver interface {
+	SaveTimedLines(id string, lines []lrc.Line) error
+}
+
+type TimedLinesGetter interface {
+	GetTimedLines(id string) (postgres.Song, []lrc.Line, error)
+}
+
+type TimedLinesDeleter interface {
+	DeleteTimedLines(id string) error
+}
+
+type ExportRequest struct {
+	Format string `form:"format"`
+}
+
+type AtRequest struct {
+	OffsetMS int64 `form:"offset_ms"`
+}
+
+// Active is the line of synced lyrics active at a playback offset
+type Active struct {
+	OffsetMS int64     `json:"offset_ms"`
+	Index    int       `json:"index"`
+	Line     *lrc.Line `json:"line"`
+	Next     *lrc.Line `json:"next,omitempty"`
+}
+
+/**
+ * NewImport stores the synced lyrics of the song from an LRC file
+ * NewImport godoc
+ * @Summary Imports synced lyrics
+ * @Tags synced
+ * @Description Replaces the time-synced lyrics of the song with the lines of the LRC file in the request body.
+ * @Accept text/plain
+ * @Param id path string true "The id of the song"
+ * @Param request body string true "The LRC file"
+ * @Success 200 {object} response "The imported lines"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Song not found"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/songs/{id}/synced [put]
+ */
+func NewImport(log *logger.Logger, saver TimedLinesSaver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.synced.NewImport"
+
+		id := c.Param("id")
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxLRCSize+1))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			return
+		}
+		if len(body) > maxLRCSize {
+			c.JSON(http.StatusRequestEntityTooLarge, response.Error(errors.New("LRC file is too large")))
+			return
+		}
+
+		lines, _, err := lrc.Parse(string(body))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			log.LogError("error happened at handler",
+				zap.String("op:", op),
+				zap.Error(err))
+
+			return
+		}
+
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("id", id),
+			zap.Int("lines", len(lines)))
+
+		if err := saver.SaveTimedLines(id, lines); err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error saving the synced lyrics at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.JSON(http.StatusOK, response.OK(lines))
+	}
+}
+
+/**
+ * NewExport returns the synced lyrics of the song
+ * NewExport godoc
+ * @Summary Exports synced lyrics
+ * @Tags synced
+ * @Description Returns the time-synced lyrics of the song as JSON, an LRC file or WebVTT subtitles.
+ * @Param id path string true "The id of the song"
+ * @Param format query string false "The format: json (default), lrc or vtt"
+ * @Success 200 {object} response "The synced lyrics"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Song or synced lyrics not found"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/songs/{id}/synced [get]
+ */
+func NewExport(log *logger.Logger, getter TimedLinesGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.synced.NewExport"
+
+		id := c.Param("id")
+
+		var req ExportRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			return
+		}
+		if req.Format == "" {
+			req.Format = formatJSON
+		}
+		if req.Format != formatJSON && req.Format != formatLRC && req.Format != formatVTT {
+			c.JSON(http.StatusBadRequest, response.Error(errors.New("format must be json, lrc or vtt")))
+			return
+		}
+
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("id", id),
+			zap.String("format", req.Format))
+
+		song, lines, err := getter.GetTimedLines(id)
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error getting the synced lyrics at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		switch req.Format {
+		case formatLRC:
+			c.Data(http.StatusOK, "text/plain; charset=utf-8",
+				[]byte(lrc.Format(lines, lrc.Meta{Artist: song.Group, Title: song.Song})))
+		case formatVTT:
+			c.Data(http.StatusOK, "text/vtt; charset=utf-8", []byte(lrc.FormatVTT(lines, lastLineDuration)))
+		default:
+			c.JSON(http.StatusOK, response.OK(lines))
+		}
+	}
+}
+
+/**
+ * NewAt returns the line of the synced lyrics active at the playback offset
+ * NewAt godoc
+ * @Summary Gets the active synced line
+ * @Tags synced
+ * @Description Returns the line of the time-synced lyrics shown at the playback offset and the next one.
+ * @Param id path string true "The id of the song"
+ * @Param offset_ms query integer true "The playback offset in milliseconds"
+ * @Success 200 {object} Active "The active line, null before the first one"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Song or synced lyrics not found"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/songs/{id}/synced/at [get]
+ */
+func NewAt(log *logger.Logger, getter TimedLinesGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.synced.NewAt"
+
+		id := c.Param("id")
+
+		var req AtRequest
+		if err := c.ShouldBindQuery(&req); err != nil || req.OffsetMS < 0 {
+			c.JSON(http.StatusBadRequest, response.Error(errors.New("offset_ms must be a non-negative number")))
+			return
+		}
+
+		_, lines, err := getter.GetTimedLines(id)
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error getting the synced lyrics at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		active := Active{
+			OffsetMS: req.OffsetMS,
+			Index:    lrc.At(lines, time.Duration(req.OffsetMS)*time.Millisecond),
+		}
+		if active.Index >= 0 {
+			active.Line = &lines[active.Index]
+		}
+		if active.Index+1 < len(lines) {
+			active.Next = &lines[active.Index+1]
+		}
+
+		c.JSON(http.StatusOK, response.OK(active))
+	}
+}
+
+/**
+ * NewDelete deletes the synced lyrics of the song
+ * NewDelete godoc
+ * @Summary Deletes synced lyrics
+ * @Tags synced
+ * @Param id path string true "The id of the song"
+ * @Success 200 {object} response "Synced lyrics deleted successfully"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Synced lyrics not found"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/songs/{id}/synced [delete]
+ */
+func NewDelete(log *logger.Logger, deleter TimedLinesDeleter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.synced.NewDelete"
+
+		id := c.Param("id")
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("id", id))
+
+		if err := deleter.DeleteTimedLines(id); err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error deleting the synced lyrics at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.JSON(http.StatusOK, response.OK(nil))
+	}
+}
+
+// errorStatus maps storage errors to the response status code
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, postgres.ErrInvalidID):
+		return http.StatusBadRequest
+	case errors.Is(err, postgres.ErrNotFound), errors.Is(err, postgres.ErrNoTimedLines):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
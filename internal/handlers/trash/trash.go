@@ -0,0 +1,147 @@
+package trash
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/foreground-eclipse/song-library/internal/lib/api/author"
+	"github.com/foreground-eclipse/song-library/internal/lib/api/etag"
+	"github.com/foreground-eclipse/song-library/internal/lib/api/response"
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type Request struct {
+	Page     int `form:"page"`
+	PageSize int `form:"page_size"`
+}
+
+type TrashLister interface {
+	ListTrash(page, pageSize int) ([]postgres.TrashedSong, int, error)
+}
+
+type SongRestorer interface {
+	RestoreSong(id string, change postgres.Change) (postgres.Song, error)
+}
+
+/**
+ * NewList returns a page of deleted songs
+ * NewList godoc
+ * @Summary Lists the trash
+ * @Tags trash
+ * @Description Lists deleted songs that are not purged yet, most recently deleted first.
+ * @Param page query integer false "The page number, starting from 1"
+ * @Param page_size query integer false "The number of songs on a page, up to 100"
+ * @Success 200 {object} response "The page of deleted songs"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/trash [get]
+ */
+func NewList(log *logger.Logger, trashLister TrashLister) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.trash.NewList"
+
+		var req Request
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			log.LogError("error happened at handler",
+				zap.String("op:", op),
+				zap.Error(err))
+
+			return
+		}
+
+		if req.Page == 0 {
+			req.Page = 1
+		}
+		if req.PageSize == 0 {
+			req.PageSize = defaultPageSize
+		}
+		if req.Page < 0 || req.PageSize < 0 || req.PageSize > maxPageSize {
+			c.JSON(http.StatusBadRequest, response.Error(errors.New("invalid page or page_size")))
+			return
+		}
+
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.Int("page", req.Page),
+			zap.Int("page_size", req.PageSize))
+
+		songs, total, err := trashLister.ListTrash(req.Page, req.PageSize)
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error listing the trash at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.JSON(http.StatusOK, response.OKPage(songs, req.Page, req.PageSize, total))
+	}
+}
+
+/**
+ * NewRestore brings the song back from the trash
+ * NewRestore godoc
+ * @Summary Restores a deleted song
+ * @Tags trash
+ * @Description Brings the deleted song with given id back from the trash.
+ * @Param id path string true "The id of the song"
+ * @Param If-Match header string false "The ETag of the deleted song version"
+ * @Success 200 {object} Song "The restored song"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Song is not in the trash"
+ * @Failure 412 {object} response "Song was changed since it was read"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/trash/{id}/restore [post]
+ */
+func NewRestore(log *logger.Logger, songRestorer SongRestorer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.trash.NewRestore"
+
+		id := c.Param("id")
+
+		version, err := etag.ParseIfMatch(c.GetHeader("If-Match"))
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			return
+		}
+
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("id", id))
+
+		song, err := songRestorer.RestoreSong(id, postgres.Change{Version: version, Author: author.From(c)})
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error restoring the song at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.Header("ETag", etag.Format(song.Version))
+		c.JSON(http.StatusOK, response.OK(song))
+	}
+}
+
+// errorStatus maps storage errors to the response status code
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, postgres.ErrInvalidID),
+		errors.Is(err, postgres.ErrInvalidPage),
+		errors.Is(err, etag.ErrMultipleETags):
+		return http.StatusBadRequest
+	case errors.Is(err, postgres.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, etag.ErrInvalidETag), errors.Is(err, postgres.ErrVersionConflict):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,227 @@
+package update
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/foreground-eclipse/song-library/internal/lib/api/author"
+	"github.com/foreground-eclipse/song-library/internal/lib/api/etag"
+	"github.com/foreground-eclipse/song-library/internal/lib/api/response"
+	"github.com/foreground-eclipse/song-library/internal/lib/releasedate"
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// MergePatchContentType is the media type of RFC 7396 JSON Merge Patch documents
+const MergePatchContentType = "application/merge-patch+json"
+
+type SongPatcher interface {
+	PatchSong(id string, patch postgres.SongPatch, change postgres.Change) (postgres.Song, error)
+	PatchSongByName(group, song string, patch postgres.SongPatch, change postgres.Change) (postgres.Song, error)
+}
+
+type NameQuery struct {
+	Group string `form:"group" validate:"required"`
+	Song  string `form:"song" validate:"required"`
+}
+
+/**
+ * NewPatch changes the given attributes of the song with given id
+ * NewPatch godoc
+ * @Summary Partially updates a song by id
+ * @Tags song
+ * @Description Applies a JSON Merge Patch (RFC 7396) document to the song, only the supplied fields are changed. A null release_date removes the date.
+ * @Accept application/merge-patch+json
+ * @Param id path string true "The id of the song"
+ * @Param request body object true "The merge patch document"
+ * @Param If-Match header string false "The ETag of the song version being updated"
+ * @Success 200 {object} Song "The updated song"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Song not found"
+ * @Failure 412 {object} response "Song was changed since it was read"
+ * @Failure 415 {object} response "Unsupported media type"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/songs/{id} [patch]
+ */
+func NewPatch(log *logger.Logger, songPatcher SongPatcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.update.NewPatch"
+
+		id := c.Param("id")
+
+		patch, status, err := bindMergePatch(c)
+		if err != nil {
+			c.JSON(status, response.Error(err))
+			log.LogError("error happened at handler",
+				zap.String("op:", op),
+				zap.Error(err))
+
+			return
+		}
+
+		version, err := etag.ParseIfMatch(c.GetHeader("If-Match"))
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			return
+		}
+
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("id", id))
+
+		song, err := songPatcher.PatchSong(id, patch, postgres.Change{Version: version, Author: author.From(c)})
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error updating the song at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.Header("ETag", etag.Format(song.Version))
+		c.JSON(http.StatusOK, response.OK(song))
+	}
+}
+
+/**
+ * NewPatchByName changes the given attributes of the song with given group and song
+ * NewPatchByName godoc
+ * @Summary Partially updates a song by group and name
+ * @Tags song
+ * @Description Applies a JSON Merge Patch (RFC 7396) document to the song, which may rename its group and song. A null release_date removes the date.
+ * @Accept application/merge-patch+json
+ * @Param group query string true "The current group of the song"
+ * @Param song query string true "The current name of the song"
+ * @Param request body object true "The merge patch document"
+ * @Param If-Match header string false "The ETag of the song version being updated"
+ * @Success 200 {object} Song "The updated song"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Song not found"
+ * @Failure 412 {object} response "Song was changed since it was read"
+ * @Failure 415 {object} response "Unsupported media type"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/song/update [patch]
+ */
+func NewPatchByName(log *logger.Logger, songPatcher SongPatcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.update.NewPatchByName"
+
+		var query NameQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			log.LogError("error happened at handler",
+				zap.String("op:", op),
+				zap.Error(err))
+
+			return
+		}
+		if query.Group == "" || query.Song == "" {
+			c.JSON(http.StatusBadRequest, response.Error(errors.New("missing required fields")))
+			return
+		}
+
+		patch, status, err := bindMergePatch(c)
+		if err != nil {
+			c.JSON(status, response.Error(err))
+			log.LogError("error happened at handler",
+				zap.String("op:", op),
+				zap.Error(err))
+
+			return
+		}
+
+		version, err := etag.ParseIfMatch(c.GetHeader("If-Match"))
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			return
+		}
+
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("group", query.Group),
+			zap.String("song", query.Song))
+
+		song, err := songPatcher.PatchSongByName(query.Group, query.Song, patch, postgres.Change{Version: version, Author: author.From(c)})
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error updating the song at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.Header("ETag", etag.Format(song.Version))
+		c.JSON(http.StatusOK, response.OK(song))
+	}
+}
+
+// bindMergePatch reads the merge patch document of the request and returns
+// the response status code to use when it is not acceptable
+func bindMergePatch(c *gin.Context) (postgres.SongPatch, int, error) {
+	var patch postgres.SongPatch
+
+	if ct := c.GetHeader("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil || (mediaType != MergePatchContentType && mediaType != "application/json") {
+			return patch, http.StatusUnsupportedMediaType,
+				fmt.Errorf("content type must be %s", MergePatchContentType)
+		}
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return patch, http.StatusBadRequest, err
+	}
+
+	// a merge patch that is not an object replaces the whole target
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(body, &doc); err != nil || doc == nil {
+		return patch, http.StatusBadRequest, errors.New("merge patch must be a JSON object")
+	}
+
+	fields := map[string]**string{
+		"group":        &patch.Group,
+		"song":         &patch.Song,
+		"release_date": &patch.ReleaseDate,
+		"text":         &patch.Text,
+		"link":         &patch.Link,
+	}
+	for key, raw := range doc {
+		field, ok := fields[key]
+		if !ok {
+			return patch, http.StatusBadRequest, fmt.Errorf("unknown field %q", key)
+		}
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			// only the release date is optional, removing it makes it unknown
+			if key != "release_date" {
+				return patch, http.StatusBadRequest, fmt.Errorf("field %q cannot be removed", key)
+			}
+			unknown := ""
+			*field = &unknown
+			continue
+		}
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return patch, http.StatusBadRequest, fmt.Errorf("field %q must be a string", key)
+		}
+		if value == "" {
+			return patch, http.StatusBadRequest, fmt.Errorf("field %q cannot be empty", key)
+		}
+		*field = &value
+	}
+
+	if patch.ReleaseDate != nil && *patch.ReleaseDate != "" {
+		date, err := releasedate.Parse(*patch.ReleaseDate)
+		if err != nil {
+			return patch, http.StatusBadRequest, err
+		}
+		patch.ReleaseDate = &date
+	}
+
+	return patch, 0, nil
+}
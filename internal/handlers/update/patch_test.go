@@ -0,0 +1,81 @@
+package update
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"github.com/gin-gonic/gin"
+)
+
+func TestBindMergePatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ptr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        postgres.SongPatch
+		wantStatus  int
+	}{
+		{
+			name:        "fields",
+			contentType: MergePatchContentType,
+			body:        `{"text": "lyrics", "release_date": "16.07.2006"}`,
+			want:        postgres.SongPatch{Text: ptr("lyrics"), ReleaseDate: ptr("2006-07-16")},
+		},
+		{
+			name: "no content type",
+			body: `{"link": "http://example.com"}`,
+			want: postgres.SongPatch{Link: ptr("http://example.com")},
+		},
+		{
+			name:        "removed release date",
+			contentType: MergePatchContentType,
+			body:        `{"release_date": null}`,
+			want:        postgres.SongPatch{ReleaseDate: ptr("")},
+		},
+		{
+			name:        "empty patch",
+			contentType: MergePatchContentType,
+			body:        `{}`,
+		},
+		{name: "removed text", body: `{"text": null}`, wantStatus: http.StatusBadRequest},
+		{name: "empty group", body: `{"group": ""}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown field", body: `{"album": "x"}`, wantStatus: http.StatusBadRequest},
+		{name: "not a string", body: `{"song": 1}`, wantStatus: http.StatusBadRequest},
+		{name: "not an object", body: `["text"]`, wantStatus: http.StatusBadRequest},
+		{name: "invalid date", body: `{"release_date": "someday"}`, wantStatus: http.StatusBadRequest},
+		{
+			name:        "unsupported media type",
+			contentType: "text/plain",
+			body:        `{}`,
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				c.Request.Header.Set("Content-Type", tt.contentType)
+			}
+
+			got, status, err := bindMergePatch(c)
+			if status != tt.wantStatus {
+				t.Fatalf("bindMergePatch() status = %d (%v), want %d", status, err, tt.wantStatus)
+			}
+			if (err != nil) != (tt.wantStatus != 0) {
+				t.Fatalf("bindMergePatch() error = %v", err)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("bindMergePatch() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/foreground-eclipse/song-library/internal/lib/api/author"
+	"github.com/foreground-eclipse/song-library/internal/lib/api/etag"
 	"github.com/foreground-eclipse/song-library/internal/lib/api/response"
+	"github.com/foreground-eclipse/song-library/internal/lib/releasedate"
 	"github.com/foreground-eclipse/song-library/internal/logger"
 	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
 	"github.com/gin-gonic/gin"
@@ -20,7 +23,7 @@ type Request struct {
 }
 
 type SongUpdater interface {
-	UpdateSong(song postgres.Song) error
+	UpdateSong(song postgres.Song, change postgres.Change) (postgres.Song, error)
 }
 
 // New updates the song and sets the given attributes
@@ -30,7 +33,9 @@ func New(log *logger.Logger, songUpdate SongUpdater) gin.HandlerFunc {
 	 * @Description Updates an existing song in the database with the given attributes.
 	 * @Param Request body required true "The updated song attributes"
 	 * @Success 200 {object} Song "The updated song"
+	 * @Param If-Match header string false "The ETag of the song version being updated"
 	 * @Failure 400 {object} response "Bad request"
+	 * @Failure 412 {object} response "Song was changed since it was read"
 	 * @Failure 500 {object} response "Internal server error"
 	 * @Router /song [put]
 	 */
@@ -59,6 +64,21 @@ func New(log *logger.Logger, songUpdate SongUpdater) gin.HandlerFunc {
 			return
 		}
 
+		if req.ReleaseDate != "" {
+			date, err := releasedate.Parse(req.ReleaseDate)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, response.Error(err))
+				return
+			}
+			req.ReleaseDate = date
+		}
+
+		version, err := etag.ParseIfMatch(c.GetHeader("If-Match"))
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			return
+		}
+
 		song := postgres.Song{
 			Group:       req.Group,
 			Song:        req.Song,
@@ -67,15 +87,113 @@ func New(log *logger.Logger, songUpdate SongUpdater) gin.HandlerFunc {
 			Link:        req.Link,
 		}
 
-		err := songUpdate.UpdateSong(song)
+		song, err = songUpdate.UpdateSong(song, postgres.Change{Version: version, Author: author.From(c)})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, response.Error(err))
+			c.JSON(errorStatus(err), response.Error(err))
 			log.LogError("error getting the song details at ", zap.String("op", op),
 				zap.Error(err))
 
 			return
 		}
 
+		c.Header("ETag", etag.Format(song.Version))
+		c.JSON(http.StatusOK, response.OK(song))
+	}
+}
+
+type SongByIDUpdater interface {
+	UpdateSongByID(id string, song postgres.Song, change postgres.Change) (postgres.Song, error)
+}
+
+/**
+ * NewByID replaces all the attributes of the song with given id
+ * NewByID godoc
+ * @Summary Replaces a song by id
+ * @Tags song
+ * @Description Sets all the attributes of the song with given id, including its group and name.
+ * @Param id path string true "The id of the song"
+ * @Param request body Request true "The new song attributes"
+ * @Param If-Match header string false "The ETag of the song version being replaced"
+ * @Success 200 {object} Song "The updated song"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Song not found"
+ * @Failure 412 {object} response "Song was changed since it was read"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/songs/{id} [put]
+ */
+func NewByID(log *logger.Logger, songUpdate SongByIDUpdater) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.update.NewByID"
+
+		id := c.Param("id")
+
+		var req Request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			log.LogError("error happened at handler",
+				zap.String("op:", op),
+				zap.Error(err))
+
+			return
+		}
+
+		if req.Group == "" || req.Song == "" || req.ReleaseDate == "" || req.Text == "" || req.Link == "" {
+			c.JSON(http.StatusBadRequest, response.Error(errors.New("missing required fields")))
+			return
+		}
+
+		if req.ReleaseDate != "" {
+			date, err := releasedate.Parse(req.ReleaseDate)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, response.Error(err))
+				return
+			}
+			req.ReleaseDate = date
+		}
+
+		version, err := etag.ParseIfMatch(c.GetHeader("If-Match"))
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			return
+		}
+
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("id", id),
+			zap.String("group", req.Group),
+			zap.String("song", req.Song))
+
+		song, err := songUpdate.UpdateSongByID(id, postgres.Song{
+			Group:       req.Group,
+			Song:        req.Song,
+			ReleaseDate: req.ReleaseDate,
+			Text:        req.Text,
+			Link:        req.Link,
+		}, postgres.Change{Version: version, Author: author.From(c)})
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error updating the song at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.Header("ETag", etag.Format(song.Version))
 		c.JSON(http.StatusOK, response.OK(song))
 	}
 }
+
+// errorStatus maps storage errors to the response status code
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, postgres.ErrInvalidID):
+		return http.StatusBadRequest
+	case errors.Is(err, postgres.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, etag.ErrMultipleETags):
+		return http.StatusBadRequest
+	case errors.Is(err, etag.ErrInvalidETag), errors.Is(err, postgres.ErrVersionConflict):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
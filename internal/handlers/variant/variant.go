@@ -0,0 +1,343 @@
+package variant
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/foreground-eclipse/song-library/internal/lib/api/langneg"
+	"github.com/foreground-eclipse/song-library/internal/lib/api/response"
+	"github.com/foreground-eclipse/song-library/internal/lib/lyrics"
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"github.com/foreground-eclipse/song-library/internal/storage/postgres"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type Request struct {
+	Kind string `json:"kind"`
+	Text string `json:"text" validate:"required"`
+}
+
+type KindQuery struct {
+	Kind string `form:"kind"`
+}
+
+type AlignQuery struct {
+	Lang string `form:"lang" validate:"required"`
+	Kind string `form:"kind"`
+}
+
+type VariantSaver interface {
+	SaveVariant(v postgres.Variant) (postgres.Variant, error)
+}
+
+type VariantLister interface {
+	ListVariants(id string) ([]postgres.Variant, error)
+}
+
+type VariantDeleter interface {
+	DeleteVariant(id, lang, kind string) error
+}
+
+type VariantAligner interface {
+	GetSongByID(id string) (postgres.Song, error)
+	GetVariant(id, lang, kind string) (postgres.Variant, error)
+}
+
+// AlignedVerse is a verse of the original lyrics side by side with the
+// same verse of a variant
+type AlignedVerse struct {
+	Index    int    `json:"index"`
+	Original string `json:"original"`
+	Variant  string `json:"variant"`
+}
+
+/**
+ * NewSave adds or replaces a lyrics variant of the song
+ * NewSave godoc
+ * @Summary Saves a lyrics variant
+ * @Tags variant
+ * @Description Adds or replaces the translation or transliteration of the song lyrics in the language.
+ * @Param id path string true "The id of the song"
+ * @Param lang path string true "The BCP 47 language tag, e.g. en or ja-Latn"
+ * @Param request body Request true "The variant kind (translation by default) and text"
+ * @Success 200 {object} Variant "The saved variant"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Song not found"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/songs/{id}/variants/{lang} [put]
+ */
+func NewSave(log *logger.Logger, saver VariantSaver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.variant.NewSave"
+
+		id := c.Param("id")
+		lang, err := langneg.Canonical(c.Param("lang"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			return
+		}
+
+		var req Request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			log.LogError("error happened at handler",
+				zap.String("op:", op),
+				zap.Error(err))
+
+			return
+		}
+		if req.Kind == "" {
+			req.Kind = postgres.KindTranslation
+		}
+		if req.Text == "" {
+			c.JSON(http.StatusBadRequest, response.Error(errors.New("missing required fields")))
+			return
+		}
+
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("id", id),
+			zap.String("lang", lang),
+			zap.String("kind", req.Kind))
+
+		v, err := saver.SaveVariant(postgres.Variant{
+			SongID: id,
+			Lang:   lang,
+			Kind:   req.Kind,
+			Text:   req.Text,
+		})
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error saving the variant at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.JSON(http.StatusOK, response.OK(v))
+	}
+}
+
+/**
+ * NewList returns all the lyrics variants of the song
+ * NewList godoc
+ * @Summary Lists lyrics variants
+ * @Tags variant
+ * @Param id path string true "The id of the song"
+ * @Success 200 {object} response "The variants of the song lyrics"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/songs/{id}/variants [get]
+ */
+func NewList(log *logger.Logger, lister VariantLister) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.variant.NewList"
+
+		id := c.Param("id")
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("id", id))
+
+		variants, err := lister.ListVariants(id)
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error listing the variants at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.JSON(http.StatusOK, response.OK(variants))
+	}
+}
+
+/**
+ * NewDelete deletes a lyrics variant of the song
+ * NewDelete godoc
+ * @Summary Deletes a lyrics variant
+ * @Tags variant
+ * @Param id path string true "The id of the song"
+ * @Param lang path string true "The BCP 47 language tag"
+ * @Param kind query string false "The variant kind, translation by default"
+ * @Success 200 {object} response "Variant deleted successfully"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Variant not found"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/songs/{id}/variants/{lang} [delete]
+ */
+func NewDelete(log *logger.Logger, deleter VariantDeleter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.variant.NewDelete"
+
+		id := c.Param("id")
+		lang, err := langneg.Canonical(c.Param("lang"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			return
+		}
+
+		var query KindQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			return
+		}
+		if query.Kind == "" {
+			query.Kind = postgres.KindTranslation
+		}
+
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("id", id),
+			zap.String("lang", lang),
+			zap.String("kind", query.Kind))
+
+		if err := deleter.DeleteVariant(id, lang, query.Kind); err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error deleting the variant at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		c.JSON(http.StatusOK, response.OK(nil))
+	}
+}
+
+/**
+ * NewAlign returns the verses of the original lyrics side by side with a variant
+ * NewAlign godoc
+ * @Summary Aligns lyrics with a variant
+ * @Tags variant
+ * @Description Pairs every verse of the original lyrics with the verse of the variant at the same position.
+ * @Param id path string true "The id of the song"
+ * @Param lang query string true "The BCP 47 language tag of the variant"
+ * @Param kind query string false "The variant kind, translation by default"
+ * @Success 200 {object} response "The aligned verses"
+ * @Failure 400 {object} response "Bad request"
+ * @Failure 404 {object} response "Song or variant not found"
+ * @Failure 500 {object} response "Internal server error"
+ * @Router /api/v1/songs/{id}/variants/align [get]
+ */
+func NewAlign(log *logger.Logger, aligner VariantAligner) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.variant.NewAlign"
+
+		id := c.Param("id")
+
+		var query AlignQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			return
+		}
+		lang, err := langneg.Canonical(query.Lang)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(err))
+			return
+		}
+		if query.Kind == "" {
+			query.Kind = postgres.KindTranslation
+		}
+
+		log.LogInfo("got new request at", zap.String("op", op),
+			zap.String("id", id),
+			zap.String("lang", lang),
+			zap.String("kind", query.Kind))
+
+		song, err := aligner.GetSongByID(id)
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error getting the song details at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+		v, err := aligner.GetVariant(id, lang, query.Kind)
+		if err != nil {
+			c.JSON(errorStatus(err), response.Error(err))
+			log.LogError("error getting the variant at ", zap.String("op", op),
+				zap.Error(err))
+
+			return
+		}
+
+		original := lyrics.Verses(song.Text)
+		translated := lyrics.Verses(v.Text)
+		aligned := make([]AlignedVerse, max(len(original), len(translated)))
+		for i := range aligned {
+			aligned[i].Index = i + 1
+			if i < len(original) {
+				aligned[i].Original = original[i]
+			}
+			if i < len(translated) {
+				aligned[i].Variant = translated[i]
+			}
+		}
+
+		c.Header("Content-Language", lang)
+		c.JSON(http.StatusOK, response.OK(aligned))
+	}
+}
+
+// errorStatus maps storage errors to the response status code
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, postgres.ErrInvalidID),
+		errors.Is(err, postgres.ErrInvalidKind),
+		errors.Is(err, langneg.ErrInvalidTag):
+		return http.StatusBadRequest
+	case errors.Is(err, postgres.ErrNotFound),
+		errors.Is(err, postgres.ErrVariantNotFound),
+		errors.Is(err, langneg.ErrNoMatch):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// KindOriginal selects the original lyrics of the song in negotiation
+const KindOriginal = "original"
+
+/**
+ * Negotiate picks the lyrics variant of the song best matching the lang
+ * query parameter or the Accept-Language header of the request, the original
+ * lyrics count as available in the language of the song.
+ * The kind query parameter selects translations (default), transliterations
+ * or the original lyrics. The returned flag is unset when the original lyrics
+ * should be served.
+ */
+func Negotiate(c *gin.Context, lister VariantLister, song postgres.Song) (postgres.Variant, bool, error) {
+	c.Header("Vary", "Accept-Language")
+
+	pref, err := langneg.FromRequest(c)
+	if err != nil || !pref.Requested() {
+		return postgres.Variant{}, false, err
+	}
+
+	kind := c.DefaultQuery("kind", postgres.KindTranslation)
+	if kind == KindOriginal {
+		return postgres.Variant{}, false, nil
+	}
+	if !postgres.ValidKind(kind) {
+		return postgres.Variant{}, false, postgres.ErrInvalidKind
+	}
+
+	variants, err := lister.ListVariants(song.ID)
+	if err != nil {
+		return postgres.Variant{}, false, err
+	}
+
+	candidates := make([]postgres.Variant, 0, len(variants))
+	langs := make([]string, 0, len(variants))
+	for _, v := range variants {
+		if v.Kind == kind {
+			candidates = append(candidates, v)
+			langs = append(langs, v.Lang)
+		}
+	}
+
+	i, err := pref.Pick(song.Language, langs)
+	if err != nil || i < 0 {
+		return postgres.Variant{}, false, err
+	}
+
+	c.Header("Content-Language", candidates[i].Lang)
+	return candidates[i], true, nil
+}
@@ -0,0 +1,134 @@
+package analysis
+
+import (
+	"embed"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/foreground-eclipse/song-library/internal/lib/lyrics"
+)
+
+// WordsPerMinute is the reading speed the reading time is estimated with
+const WordsPerMinute = 200
+
+//go:embed stopwords/*.txt
+var stopwordFiles embed.FS
+
+// Stats are the statistics of lyrics
+type Stats struct {
+	Lines       int         `json:"lines"`
+	Verses      int         `json:"verses"`
+	Words       int         `json:"words"`
+	UniqueWords int         `json:"unique_words"`
+	TopWords    []WordCount `json:"top_words"`
+	// RepetitionRatio is the share of lines repeating an earlier line
+	RepetitionRatio float64 `json:"repetition_ratio"`
+	// ReadingTime is the estimated reading time in seconds
+	ReadingTime int `json:"reading_time"`
+}
+
+// WordCount is the number of occurrences of a word
+type WordCount struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+// StopWords is a set of words too common to be telling
+type StopWords map[string]struct{}
+
+// StopWordsFor returns the stop words of the languages given by BCP 47 tags,
+// languages without a list add no stop words
+func StopWordsFor(langs ...string) StopWords {
+	stop := make(StopWords)
+	for _, lang := range langs {
+		base, _, _ := strings.Cut(strings.ToLower(lang), "-")
+		data, err := stopwordFiles.ReadFile("stopwords/" + base + ".txt")
+		if err != nil {
+			continue
+		}
+		for _, w := range strings.Fields(string(data)) {
+			stop[w] = struct{}{}
+		}
+	}
+	return stop
+}
+
+// Analyze computes the statistics of the lyrics with up to top most
+// frequent words that are not stop words
+func Analyze(text string, stop StopWords, top int) Stats {
+	return AnalyzeAll([]string{text}, stop, top)
+}
+
+// AnalyzeAll computes the statistics of the lyrics of several songs taken
+// together, lines are repeated only within a song
+func AnalyzeAll(texts []string, stop StopWords, top int) Stats {
+	var stats Stats
+	repeated := 0
+	counts := make(map[string]int)
+
+	for _, text := range texts {
+		seen := make(map[string]bool)
+		verses := lyrics.Verses(text)
+		stats.Verses += len(verses)
+		for _, verse := range verses {
+			for _, line := range strings.Split(verse, "\n") {
+				line = strings.TrimSpace(line)
+				if line == "" {
+					continue
+				}
+				stats.Lines++
+
+				words := Words(line)
+				key := strings.Join(words, " ")
+				if seen[key] {
+					repeated++
+				}
+				seen[key] = true
+
+				for _, w := range words {
+					stats.Words++
+					counts[w]++
+				}
+			}
+		}
+	}
+
+	stats.UniqueWords = len(counts)
+	if stats.Lines > 0 {
+		stats.RepetitionRatio = math.Round(float64(repeated)/float64(stats.Lines)*1000) / 1000
+	}
+	stats.ReadingTime = int(math.Ceil(float64(stats.Words) * 60 / WordsPerMinute))
+	stats.TopWords = topWords(counts, stop, top)
+
+	return stats
+}
+
+// Words splits the text into lowercase words
+func Words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\'' && r != '’'
+	})
+}
+
+// topWords returns up to top most frequent words that are not stop words,
+// words of equal frequency are ordered alphabetically
+func topWords(counts map[string]int, stop StopWords, top int) []WordCount {
+	words := make([]WordCount, 0, len(counts))
+	for w, n := range counts {
+		if _, ok := stop[w]; ok {
+			continue
+		}
+		words = append(words, WordCount{Word: w, Count: n})
+	}
+
+	sort.Slice(words, func(i, j int) bool {
+		if words[i].Count != words[j].Count {
+			return words[i].Count > words[j].Count
+		}
+		return words[i].Word < words[j].Word
+	})
+
+	return words[:min(top, len(words))]
+}
@@ -0,0 +1,129 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", []string{}},
+		{"Hello, World!", []string{"hello", "world"}},
+		{"Don't stop me now", []string{"don't", "stop", "me", "now"}},
+		{"It’s 1999 — party", []string{"it’s", "1999", "party"}},
+		{"Звезда по имени Солнце", []string{"звезда", "по", "имени", "солнце"}},
+		{"rock-n-roll", []string{"rock", "n", "roll"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := Words(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Words() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStopWordsFor(t *testing.T) {
+	tests := []struct {
+		name  string
+		langs []string
+		stop  []string
+		keep  []string
+	}{
+		{name: "english", langs: []string{"en"}, stop: []string{"the", "don't"}, keep: []string{"love", "и"}},
+		{name: "region subtag", langs: []string{"en-GB"}, stop: []string{"the"}},
+		{name: "several languages", langs: []string{"en", "ru"}, stop: []string{"the", "и"}},
+		{name: "unknown language", langs: []string{"xx"}, keep: []string{"the"}},
+		{name: "undetermined", langs: []string{"", "und"}, keep: []string{"the"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stop := StopWordsFor(tt.langs...)
+			for _, w := range tt.stop {
+				if _, ok := stop[w]; !ok {
+					t.Errorf("StopWordsFor(%q) lacks %q", tt.langs, w)
+				}
+			}
+			for _, w := range tt.keep {
+				if _, ok := stop[w]; ok {
+					t.Errorf("StopWordsFor(%q) has %q", tt.langs, w)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		top  int
+		want Stats
+	}{
+		{
+			name: "empty",
+			top:  3,
+			want: Stats{TopWords: []WordCount{}},
+		},
+		{
+			name: "repeated lines",
+			text: "Love me do\nyou know I love you\n\nLove me do\nLove, me do!",
+			top:  2,
+			want: Stats{
+				Lines:           4,
+				Verses:          2,
+				Words:           14,
+				UniqueWords:     6,
+				TopWords:        []WordCount{{"love", 4}, {"know", 1}},
+				RepetitionRatio: 0.5,
+				ReadingTime:     5,
+			},
+		},
+		{
+			name: "ties ordered alphabetically",
+			text: "zebra apple\nmango",
+			top:  10,
+			want: Stats{
+				Lines:       2,
+				Verses:      1,
+				Words:       3,
+				UniqueWords: 3,
+				TopWords:    []WordCount{{"apple", 1}, {"mango", 1}, {"zebra", 1}},
+				ReadingTime: 1,
+			},
+		},
+		{
+			name: "no top words",
+			text: "one two",
+			top:  0,
+			want: Stats{Lines: 1, Verses: 1, Words: 2, UniqueWords: 2, TopWords: []WordCount{}, ReadingTime: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Analyze(tt.text, StopWordsFor("en"), tt.top); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Analyze() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeAll(t *testing.T) {
+	// a line repeats only within its song
+	got := AnalyzeAll([]string{"na na na\nhey", "na na na\nna na na"}, nil, 1)
+	want := Stats{
+		Lines:           4,
+		Verses:          2,
+		Words:           10,
+		UniqueWords:     2,
+		TopWords:        []WordCount{{"na", 9}},
+		RepetitionRatio: 0.25,
+		ReadingTime:     3,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AnalyzeAll() = %+v, want %+v", got, want)
+	}
+}
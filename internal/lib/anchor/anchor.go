@@ -0,0 +1,209 @@
+package anchor
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/foreground-eclipse/song-library/internal/lib/diff"
+	"github.com/foreground-eclipse/song-library/internal/lib/lyrics"
+)
+
+var ErrInvalidRange = errors.New("the range is out of the lyrics")
+
+// minSimilarity is the least similarity of an edited line to the original
+// one for an annotation of the whole line to follow it
+const minSimilarity = 0.5
+
+// searchRadius is the number of lines around the expected position searched
+// for an edited line
+const searchRadius = 2
+
+// Anchor is a range of characters on a line of lyrics, lines are numbered
+// from 1 and the range is a half-open interval of character offsets
+type Anchor struct {
+	Line  int    `json:"line"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Quote string `json:"quote"`
+}
+
+// Lines splits the lyrics into lines the way anchors count them
+func Lines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(lyrics.Normalize(text), "\n")
+}
+
+// New returns the anchor of the range on the line of the lyrics, a range
+// with both offsets zero covers the whole line
+func New(text string, line, start, end int) (Anchor, error) {
+	lines := Lines(text)
+	if line < 1 || line > len(lines) {
+		return Anchor{}, ErrInvalidRange
+	}
+
+	runes := []rune(lines[line-1])
+	if start == 0 && end == 0 {
+		end = len(runes)
+	}
+	if start < 0 || end <= start || end > len(runes) {
+		return Anchor{}, ErrInvalidRange
+	}
+
+	return Anchor{
+		Line:  line,
+		Start: start,
+		End:   end,
+		Quote: string(runes[start:end]),
+	}, nil
+}
+
+// Mapping maps the lines of lyrics to the lines of their edited version,
+// it is computed once per edit for all the anchors on the lyrics
+type Mapping struct {
+	oldLines []string
+	newLines []string
+	// follow holds the index of the line of the new lyrics where each line
+	// of the old lyrics ended up or, when it was edited, where it would
+	// have been
+	follow []int
+}
+
+// Map returns the mapping of the lines of the old lyrics to the new ones
+func Map(oldText, newText string) Mapping {
+	m := Mapping{oldLines: Lines(oldText), newLines: Lines(newText)}
+	m.follow = make([]int, 0, len(m.oldLines))
+
+	j := 0
+	for _, l := range diff.Lines(oldText, newText) {
+		switch l.Op {
+		case diff.OpEqual:
+			m.follow = append(m.follow, j)
+			j++
+		case diff.OpDelete:
+			m.follow = append(m.follow, j)
+		case diff.OpInsert:
+			j++
+		}
+	}
+
+	return m
+}
+
+// Reanchor moves the anchor placed on the old lyrics to the nearest matching
+// text of the new lyrics. It reports false when the quoted text is gone.
+func Reanchor(a Anchor, m Mapping) (Anchor, bool) {
+	if a.Line < 1 || a.Line > len(m.oldLines) || len(m.newLines) == 0 {
+		return a, false
+	}
+
+	expected := m.follow[a.Line-1]
+
+	// the quote is still on the line or it moved the least
+	if moved, ok := nearest(a, m.newLines, expected); ok {
+		return moved, true
+	}
+
+	// an annotation of a whole edited line follows the line
+	old := m.oldLines[a.Line-1]
+	if a.Start != 0 || a.End != utf8.RuneCountInString(old) {
+		return a, false
+	}
+	bestLine, bestSimilarity := -1, 0.0
+	for i := max(expected-searchRadius, 0); i <= min(expected+searchRadius, len(m.newLines)-1); i++ {
+		if s := similarity(old, m.newLines[i]); s >= minSimilarity && s > bestSimilarity {
+			bestLine, bestSimilarity = i, s
+		}
+	}
+	if bestLine < 0 {
+		return a, false
+	}
+
+	return Anchor{
+		Line:  bestLine + 1,
+		Start: 0,
+		End:   utf8.RuneCountInString(m.newLines[bestLine]),
+		Quote: m.newLines[bestLine],
+	}, true
+}
+
+// Recover anchors an orphaned anchor on the new lyrics again when its quote
+// is back, nearest to where it was last placed
+func Recover(a Anchor, m Mapping) (Anchor, bool) {
+	return nearest(a, m.newLines, a.Line-1)
+}
+
+// nearest returns the anchor of the occurrence of the quote on the lines
+// closest to the expected line index and the offset of the anchor
+func nearest(a Anchor, lines []string, expected int) (Anchor, bool) {
+	bestLine, bestStart, bestDistance := -1, 0, 0
+	for i, line := range lines {
+		for _, start := range occurrences(line, a.Quote) {
+			distance := abs(i-expected)*1000 + abs(start-a.Start)
+			if bestLine < 0 || distance < bestDistance {
+				bestLine, bestStart, bestDistance = i, start, distance
+			}
+		}
+	}
+	if bestLine < 0 {
+		return a, false
+	}
+
+	return Anchor{
+		Line:  bestLine + 1,
+		Start: bestStart,
+		End:   bestStart + utf8.RuneCountInString(a.Quote),
+		Quote: a.Quote,
+	}, true
+}
+
+// occurrences returns the character offsets of the quote on the line
+func occurrences(line, quote string) []int {
+	if quote == "" {
+		return nil
+	}
+
+	var offsets []int
+	for from := 0; ; {
+		i := strings.Index(line[from:], quote)
+		if i < 0 {
+			return offsets
+		}
+		offsets = append(offsets, utf8.RuneCountInString(line[:from+i]))
+		_, size := utf8.DecodeRuneInString(line[from+i:])
+		from += i + size
+	}
+}
+
+// similarity returns the share of characters of the lines in their longest
+// common subsequence
+func similarity(a, b string) float64 {
+	x, y := []rune(a), []rune(b)
+	if len(x)+len(y) == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(y)+1)
+	cur := make([]int, len(y)+1)
+	for i := range x {
+		for j := range y {
+			if x[i] == y[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev, cur = cur, prev
+	}
+
+	return 2 * float64(prev[len(y)]) / float64(len(x)+len(y))
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
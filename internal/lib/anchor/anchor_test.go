@@ -0,0 +1,163 @@
+package anchor
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"empty", "", nil},
+		{"unix", "one\ntwo", []string{"one", "two"}},
+		{"windows", "one\r\ntwo", []string{"one", "two"}},
+		{"classic mac", "one\rtwo\rthree", []string{"one", "two", "three"}},
+		{"mixed", "one\r\ntwo\rthree\nfour", []string{"one", "two", "three", "four"}},
+		{"trailing break", "one\n", []string{"one", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Lines(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	const text = "Hello darkness\rmy old friend"
+
+	tests := []struct {
+		name             string
+		line, start, end int
+		want             Anchor
+		wantErr          error
+	}{
+		{name: "range", line: 2, start: 3, end: 6, want: Anchor{Line: 2, Start: 3, End: 6, Quote: "old"}},
+		{name: "whole line", line: 1, want: Anchor{Line: 1, Start: 0, End: 14, Quote: "Hello darkness"}},
+		{name: "no line", line: 3, wantErr: ErrInvalidRange},
+		{name: "zero line", line: 0, wantErr: ErrInvalidRange},
+		{name: "past the end", line: 2, start: 10, end: 20, wantErr: ErrInvalidRange},
+		{name: "empty range", line: 2, start: 3, end: 3, wantErr: ErrInvalidRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(text, tt.line, tt.start, tt.end)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("New() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("New() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReanchor(t *testing.T) {
+	const old = "Hello darkness\nmy old friend\nI've come to talk"
+
+	tests := []struct {
+		name    string
+		anchor  Anchor
+		newText string
+		want    Anchor
+		wantOK  bool
+	}{
+		{
+			name:    "unchanged",
+			anchor:  Anchor{Line: 2, Start: 3, End: 6, Quote: "old"},
+			newText: old,
+			want:    Anchor{Line: 2, Start: 3, End: 6, Quote: "old"},
+			wantOK:  true,
+		},
+		{
+			name:    "line inserted above",
+			anchor:  Anchor{Line: 2, Start: 3, End: 6, Quote: "old"},
+			newText: "Intro\n" + old,
+			want:    Anchor{Line: 3, Start: 3, End: 6, Quote: "old"},
+			wantOK:  true,
+		},
+		{
+			name:    "quote moved on the line",
+			anchor:  Anchor{Line: 2, Start: 3, End: 6, Quote: "old"},
+			newText: "Hello darkness\nmy dear old friend\nI've come to talk",
+			want:    Anchor{Line: 2, Start: 8, End: 11, Quote: "old"},
+			wantOK:  true,
+		},
+		{
+			name:    "edited whole line",
+			anchor:  Anchor{Line: 2, Start: 0, End: 13, Quote: "my old friend"},
+			newText: "Hello darkness\nmy good friend\nI've come to talk",
+			want:    Anchor{Line: 2, Start: 0, End: 14, Quote: "my good friend"},
+			wantOK:  true,
+		},
+		{
+			name:    "carriage returns",
+			anchor:  Anchor{Line: 3, Start: 5, End: 9, Quote: "come"},
+			newText: "Hello darkness\rmy old friend\rI've come to talk",
+			want:    Anchor{Line: 3, Start: 5, End: 9, Quote: "come"},
+			wantOK:  true,
+		},
+		{
+			name:    "quote gone",
+			anchor:  Anchor{Line: 2, Start: 3, End: 6, Quote: "old"},
+			newText: "Hello darkness\nmy friend\nI've come to talk",
+			want:    Anchor{Line: 2, Start: 3, End: 6, Quote: "old"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Reanchor(tt.anchor, Map(old, tt.newText))
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Reanchor() = %+v, %t, want %+v, %t", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRecover(t *testing.T) {
+	orphaned := Anchor{Line: 2, Start: 3, End: 6, Quote: "old"}
+
+	tests := []struct {
+		name    string
+		newText string
+		want    Anchor
+		wantOK  bool
+	}{
+		{
+			name:    "quote back in place",
+			newText: "Hello darkness\nmy old friend",
+			want:    orphaned,
+			wantOK:  true,
+		},
+		{
+			name:    "quote back elsewhere",
+			newText: "Hello darkness\nmy friend\nso old",
+			want:    Anchor{Line: 3, Start: 3, End: 6, Quote: "old"},
+			wantOK:  true,
+		},
+		{
+			name:    "nearest occurrence",
+			newText: "old\nHello old darkness\nmy friend\nold",
+			want:    Anchor{Line: 2, Start: 6, End: 9, Quote: "old"},
+			wantOK:  true,
+		},
+		{
+			name:    "still gone",
+			newText: "Hello darkness\nmy friend",
+			want:    orphaned,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Recover(orphaned, Map("Hello darkness\nmy friend", tt.newText))
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Recover() = %+v, %t, want %+v, %t", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package author
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Header is the request header naming the user who makes a change
+const Header = "X-User"
+
+// Anonymous is the author of changes made without the Header
+const Anonymous = "anonymous"
+
+// From returns the author of the change made by the request
+func From(c *gin.Context) string {
+	if name := strings.TrimSpace(c.GetHeader(Header)); name != "" {
+		return name
+	}
+	return Anonymous
+}
@@ -0,0 +1,59 @@
+package etag
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidETag is returned for entity tags that can never match a song version
+	ErrInvalidETag   = errors.New("entity tag does not match")
+	ErrMultipleETags = errors.New("only one entity tag is supported in If-Match")
+)
+
+// Format returns the entity tag of the given song version
+func Format(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// FormatVariant returns the entity tag of the lyrics variant served in place
+// of the original lyrics of the song version, it changes with the song version
+// and the revision of the variant and never matches in If-Match
+func FormatVariant(version int, lang, kind string, revision int64) string {
+	return `"` + strconv.Itoa(version) + "-" + lang + "-" + kind + "-" +
+		strconv.FormatInt(revision, 36) + `"`
+}
+
+// ParseIfMatch returns the song version required by the If-Match header,
+// 0 when the header is empty or matches any version
+func ParseIfMatch(header string) (int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	if strings.Contains(header, ",") {
+		return 0, ErrMultipleETags
+	}
+
+	// weak tags never match by the strong comparison If-Match requires
+	if strings.HasPrefix(header, "W/") {
+		return 0, ErrInvalidETag
+	}
+
+	tag, ok := strings.CutPrefix(header, `"`)
+	if !ok {
+		return 0, ErrInvalidETag
+	}
+	tag, ok = strings.CutSuffix(tag, `"`)
+	if !ok {
+		return 0, ErrInvalidETag
+	}
+
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		return 0, ErrInvalidETag
+	}
+
+	return version, nil
+}
@@ -0,0 +1,51 @@
+package etag
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    int
+		wantErr error
+	}{
+		{header: "", want: 0},
+		{header: "*", want: 0},
+		{header: ` "3" `, want: 3},
+		{header: Format(12), want: 12},
+		{header: `W/"3"`, wantErr: ErrInvalidETag},
+		{header: `"3", "4"`, wantErr: ErrMultipleETags},
+		{header: `3`, wantErr: ErrInvalidETag},
+		{header: `"0"`, wantErr: ErrInvalidETag},
+		{header: FormatVariant(3, "en", "translation", 42), wantErr: ErrInvalidETag},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			got, err := ParseIfMatch(tt.header)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseIfMatch() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseIfMatch() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatVariant(t *testing.T) {
+	tags := map[string]bool{Format(3): true}
+	for _, tag := range []string{
+		FormatVariant(3, "en", "translation", 1),
+		FormatVariant(3, "en", "translation", 2),
+		FormatVariant(3, "en", "transliteration", 1),
+		FormatVariant(3, "de", "translation", 1),
+		FormatVariant(4, "en", "translation", 1),
+	} {
+		if tags[tag] {
+			t.Errorf("FormatVariant() = %s, the tag of another representation", tag)
+		}
+		tags[tag] = true
+	}
+}
@@ -0,0 +1,91 @@
+package langneg
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+var (
+	ErrInvalidTag = errors.New("invalid language tag")
+	ErrNoMatch    = errors.New("lyrics are not available in the requested language")
+)
+
+// Preference is the language of the lyrics requested by a client
+type Preference struct {
+	Tags []language.Tag
+	// Explicit is set when the language is given by the lang query parameter,
+	// which must be matched, rather than by the Accept-Language header
+	Explicit bool
+}
+
+// Canonical returns the canonical form of a BCP 47 language tag
+func Canonical(tag string) (string, error) {
+	t, err := language.Parse(strings.TrimSpace(tag))
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTag, tag)
+	}
+	return t.String(), nil
+}
+
+// FromRequest returns the language requested by the lang query parameter or,
+// when it is absent, by the Accept-Language header
+func FromRequest(c *gin.Context) (Preference, error) {
+	if lang := c.Query("lang"); lang != "" {
+		t, err := language.Parse(lang)
+		if err != nil {
+			return Preference{}, fmt.Errorf("%w: %q", ErrInvalidTag, lang)
+		}
+		return Preference{Tags: []language.Tag{t}, Explicit: true}, nil
+	}
+
+	if header := c.GetHeader("Accept-Language"); header != "" {
+		// a malformed header is ignored as if it was not sent
+		tags, _, err := language.ParseAcceptLanguage(header)
+		if err == nil {
+			return Preference{Tags: tags}, nil
+		}
+	}
+
+	return Preference{}, nil
+}
+
+// Requested reports whether the client asked for a language at all
+func (p Preference) Requested() bool {
+	return len(p.Tags) > 0
+}
+
+// Pick returns the index of the available language best matching the
+// preference, or -1 when the original lyrics should be served. The original
+// lyrics in their language, when it is known, match as well as any variant.
+func (p Preference) Pick(original string, available []string) (int, error) {
+	if !p.Requested() {
+		return -1, nil
+	}
+
+	// the undetermined language stands for the original lyrics and is
+	// picked when nothing else matches
+	supported := []language.Tag{language.Und}
+	for _, a := range available {
+		supported = append(supported, language.Make(a))
+	}
+	if original != "" && original != language.Und.String() {
+		supported = append(supported, language.Make(original))
+	}
+
+	_, i, confidence := language.NewMatcher(supported).Match(p.Tags...)
+	if i == 0 || confidence < language.High {
+		if p.Explicit {
+			return -1, ErrNoMatch
+		}
+		return -1, nil
+	}
+	if i > len(available) {
+		return -1, nil
+	}
+
+	return i - 1, nil
+}
@@ -0,0 +1,50 @@
+package langneg
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestPick(t *testing.T) {
+	tests := []struct {
+		name      string
+		tags      string
+		explicit  bool
+		original  string
+		available []string
+		want      int
+		wantErr   error
+	}{
+		{name: "nothing requested", original: "ru", available: []string{"en"}, want: -1},
+		{name: "variant", tags: "en", explicit: true, original: "ru", available: []string{"de", "en"}, want: 1},
+		{name: "original language", tags: "ru", explicit: true, original: "ru", available: []string{"en"}, want: -1},
+		{name: "original without variants", tags: "ru", explicit: true, original: "ru", want: -1},
+		{name: "regional variant", tags: "en-GB", explicit: true, original: "ru", available: []string{"en"}, want: 0},
+		{name: "explicit without match", tags: "fr", explicit: true, original: "ru", available: []string{"en"}, want: -1, wantErr: ErrNoMatch},
+		{name: "undetermined original", tags: "und", explicit: true, original: "und", available: []string{"en"}, want: -1, wantErr: ErrNoMatch},
+		{name: "header without match", tags: "fr", original: "ru", available: []string{"en"}, want: -1},
+		{name: "header preference order", tags: "de, ru;q=0.9, en;q=0.8", original: "ru", available: []string{"en"}, want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Preference
+			if tt.tags != "" {
+				tags, _, err := language.ParseAcceptLanguage(tt.tags)
+				if err != nil {
+					t.Fatal(err)
+				}
+				p = Preference{Tags: tags, Explicit: tt.explicit}
+			}
+
+			got, err := p.Pick(tt.original, tt.available)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Pick() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Pick() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
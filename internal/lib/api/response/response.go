@@ -1,9 +1,28 @@
 package response
 
 type Response struct {
-	Status string      `json:"status"`
-	Error  string      `json:"error,omitempty"`
-	Data   interface{} `json:"data,omitempty"`
+	Status     string      `json:"status"`
+	Error      string      `json:"error,omitempty"`
+	Data       interface{} `json:"data,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+	Cursor     *Cursor     `json:"cursor,omitempty"`
+}
+
+// Pagination is a struct that represents paging metadata of a listing
+type Pagination struct {
+	Page       int  `json:"page"`
+	PageSize   int  `json:"page_size"`
+	Total      int  `json:"total"`
+	TotalPages int  `json:"total_pages"`
+	NextPage   *int `json:"next_page,omitempty"`
+	PrevPage   *int `json:"prev_page,omitempty"`
+}
+
+// Cursor is a struct that represents keyset paging metadata of a listing
+type Cursor struct {
+	PageSize int    `json:"page_size"`
+	Next     string `json:"next,omitempty"`
+	HasMore  bool   `json:"has_more"`
 }
 
 const (
@@ -18,6 +37,45 @@ func OK(data interface{}) Response {
 	}
 }
 
+// OKPage returns the page of data together with its pagination metadata
+func OKPage(data interface{}, page, pageSize, total int) Response {
+	p := &Pagination{
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	}
+	if pageSize > 0 {
+		p.TotalPages = (total + pageSize - 1) / pageSize
+	}
+	if page < p.TotalPages {
+		next := page + 1
+		p.NextPage = &next
+	}
+	if page > 1 {
+		prev := page - 1
+		p.PrevPage = &prev
+	}
+
+	return Response{
+		Status:     StatusOK,
+		Data:       data,
+		Pagination: p,
+	}
+}
+
+// OKCursor returns the page of data together with the cursor of the next page
+func OKCursor(data interface{}, pageSize int, next string) Response {
+	return Response{
+		Status: StatusOK,
+		Data:   data,
+		Cursor: &Cursor{
+			PageSize: pageSize,
+			Next:     next,
+			HasMore:  next != "",
+		},
+	}
+}
+
 func Error(err error) Response {
 	return Response{
 		Status: StatusError,
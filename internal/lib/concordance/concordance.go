@@ -0,0 +1,64 @@
+package concordance
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/foreground-eclipse/song-library/internal/lib/lyrics"
+)
+
+// Hit is an occurrence of a phrase in lyrics with the words around it
+type Hit struct {
+	// Verse is the number of the verse of the occurrence, starting from 1
+	Verse int    `json:"verse"`
+	Left  string `json:"left"`
+	Match string `json:"match"`
+	Right string `json:"right"`
+}
+
+// Words splits the text into words, keeping their spelling
+func Words(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\'' && r != '’'
+	})
+}
+
+// Find returns every occurrence of the phrase in the verses of the lyrics
+// with up to context words on each side within the verse, words are compared
+// case-insensitively
+func Find(text string, phrase []string, context int) []Hit {
+	if len(phrase) == 0 {
+		return nil
+	}
+	wanted := make([]string, len(phrase))
+	for i, w := range phrase {
+		wanted[i] = strings.ToLower(w)
+	}
+
+	var hits []Hit
+	for v, verse := range lyrics.Verses(text) {
+		words := Words(verse)
+		for i := 0; i+len(wanted) <= len(words); i++ {
+			if !matches(words[i:i+len(wanted)], wanted) {
+				continue
+			}
+			end := i + len(wanted)
+			hits = append(hits, Hit{
+				Verse: v + 1,
+				Left:  strings.Join(words[max(i-context, 0):i], " "),
+				Match: strings.Join(words[i:end], " "),
+				Right: strings.Join(words[end:min(end+context, len(words))], " "),
+			})
+		}
+	}
+	return hits
+}
+
+func matches(words, wanted []string) bool {
+	for i, w := range wanted {
+		if strings.ToLower(words[i]) != w {
+			return false
+		}
+	}
+	return true
+}
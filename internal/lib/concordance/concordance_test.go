@@ -0,0 +1,130 @@
+package concordance
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", []string{}},
+		{"Hello, World!", []string{"Hello", "World"}},
+		{"Don't stop me now", []string{"Don't", "stop", "me", "now"}},
+		{"It’s 1999…", []string{"It’s", "1999"}},
+		{"Звёзды\tи  небо", []string{"Звёзды", "и", "небо"}},
+		{"rock-n-roll", []string{"rock", "n", "roll"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := Words(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Words() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	const text = "Love me tender, love me sweet\nnever let me go\n\nYou have made my life complete\nand I love you so"
+
+	tests := []struct {
+		name    string
+		text    string
+		phrase  []string
+		context int
+		want    []Hit
+	}{
+		{
+			name:    "every occurrence with context",
+			text:    text,
+			phrase:  []string{"love"},
+			context: 2,
+			want: []Hit{
+				{Verse: 1, Left: "", Match: "Love", Right: "me tender"},
+				{Verse: 1, Left: "me tender", Match: "love", Right: "me sweet"},
+				{Verse: 2, Left: "and I", Match: "love", Right: "you so"},
+			},
+		},
+		{
+			name:    "context crosses lines within a verse",
+			text:    text,
+			phrase:  []string{"sweet"},
+			context: 3,
+			want:    []Hit{{Verse: 1, Left: "tender love me", Match: "sweet", Right: "never let me"}},
+		},
+		{
+			name:    "match at the end of the verse",
+			text:    text,
+			phrase:  []string{"you", "so"},
+			context: 2,
+			want:    []Hit{{Verse: 2, Left: "I love", Match: "you so", Right: ""}},
+		},
+		{
+			name:    "context stops at the verse",
+			text:    text,
+			phrase:  []string{"go"},
+			context: 20,
+			want:    []Hit{{Verse: 1, Left: "Love me tender love me sweet never let me", Match: "go", Right: ""}},
+		},
+		{
+			name:    "case-insensitive phrase",
+			text:    text,
+			phrase:  []string{"MADE", "My"},
+			context: 1,
+			want:    []Hit{{Verse: 2, Left: "have", Match: "made my", Right: "life"}},
+		},
+		{
+			name:    "no context",
+			text:    text,
+			phrase:  []string{"never"},
+			context: 0,
+			want:    []Hit{{Verse: 1, Left: "", Match: "never", Right: ""}},
+		},
+		{
+			name:    "multibyte runes",
+			text:    "Группа крови на рукаве\nмой порядковый номер на рукаве",
+			phrase:  []string{"НА", "РУКАВЕ"},
+			context: 1,
+			want: []Hit{
+				{Verse: 1, Left: "крови", Match: "на рукаве", Right: "мой"},
+				{Verse: 1, Left: "номер", Match: "на рукаве", Right: ""},
+			},
+		},
+		{
+			name:    "overlapping occurrences",
+			text:    "na na na",
+			phrase:  []string{"na", "na"},
+			context: 1,
+			want: []Hit{
+				{Verse: 1, Left: "", Match: "na na", Right: "na"},
+				{Verse: 1, Left: "na", Match: "na na", Right: ""},
+			},
+		},
+		{
+			name:    "phrase split by punctuation",
+			text:    "hold on, tight",
+			phrase:  []string{"on", "tight"},
+			context: 1,
+			want:    []Hit{{Verse: 1, Left: "hold", Match: "on tight", Right: ""}},
+		},
+		{
+			name:   "no match",
+			text:   text,
+			phrase: []string{"hate"},
+		},
+		{
+			name:   "no phrase",
+			text:   text,
+			phrase: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Find(tt.text, tt.phrase, tt.context); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Find() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
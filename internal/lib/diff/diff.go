@@ -0,0 +1,160 @@
+package diff
+
+import (
+	"strings"
+
+	"github.com/foreground-eclipse/song-library/internal/lib/lyrics"
+)
+
+// Op is a kind of a diff line
+type Op string
+
+const (
+	OpEqual  Op = "="
+	OpDelete Op = "-"
+	OpInsert Op = "+"
+)
+
+// Line is a line of a diff
+type Line struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// Lines returns the line diff turning a into b based on their longest
+// common subsequence of lines. It takes time proportional to the product of
+// the numbers of changed lines and memory proportional to their sum.
+func Lines(a, b string) []Line {
+	x := splitLines(a)
+	y := splitLines(b)
+
+	lines := make([]Line, 0, max(len(x), len(y)))
+
+	// common leading and trailing lines need no search
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+
+	for _, text := range x[:prefix] {
+		lines = append(lines, Line{Op: OpEqual, Text: text})
+	}
+	numbers := intern(x, y)
+	d := differ{x: x, y: y, xi: numbers[:len(x)], yi: numbers[len(x):]}
+	lines = d.diff(lines, prefix, len(x)-suffix, prefix, len(y)-suffix)
+	for _, text := range x[len(x)-suffix:] {
+		lines = append(lines, Line{Op: OpEqual, Text: text})
+	}
+
+	return lines
+}
+
+// intern numbers the distinct lines of both texts for cheap comparison,
+// the numbers of the lines of x come first
+func intern(x, y []string) []int {
+	ids := make(map[string]int)
+	numbers := make([]int, 0, len(x)+len(y))
+	for _, line := range append(x[:len(x):len(x)], y...) {
+		id, ok := ids[line]
+		if !ok {
+			id = len(ids)
+			ids[line] = id
+		}
+		numbers = append(numbers, id)
+	}
+	return numbers
+}
+
+// differ finds the longest common subsequence of lines by Hirschberg's
+// divide and conquer algorithm
+type differ struct {
+	x, y   []string
+	xi, yi []int
+}
+
+// diff appends the diff turning x[x0:x1] into y[y0:y1] to the lines
+func (d *differ) diff(lines []Line, x0, x1, y0, y1 int) []Line {
+	switch {
+	case x0 == x1:
+		for j := y0; j < y1; j++ {
+			lines = append(lines, Line{Op: OpInsert, Text: d.y[j]})
+		}
+		return lines
+	case y0 == y1:
+		for i := x0; i < x1; i++ {
+			lines = append(lines, Line{Op: OpDelete, Text: d.x[i]})
+		}
+		return lines
+	case x1-x0 == 1:
+		for j := y0; j < y1; j++ {
+			if d.xi[x0] == d.yi[j] {
+				lines = d.diff(lines, x0, x0, y0, j)
+				lines = append(lines, Line{Op: OpEqual, Text: d.x[x0]})
+				return d.diff(lines, x1, x1, j+1, y1)
+			}
+		}
+		lines = append(lines, Line{Op: OpDelete, Text: d.x[x0]})
+		return d.diff(lines, x1, x1, y0, y1)
+	}
+
+	// split y where the halves of x have the longest common subsequences
+	mid := (x0 + x1) / 2
+	forward := d.forward(x0, mid, y0, y1)
+	backward := d.backward(mid, x1, y0, y1)
+	split, best := y0, -1
+	for k := 0; k <= y1-y0; k++ {
+		if n := forward[k] + backward[k]; n > best {
+			split, best = y0+k, n
+		}
+	}
+
+	lines = d.diff(lines, x0, mid, y0, split)
+	return d.diff(lines, mid, x1, split, y1)
+}
+
+// forward returns the lengths of the longest common subsequences of
+// x[x0:x1] and the prefixes y[y0:y0+k]
+func (d *differ) forward(x0, x1, y0, y1 int) []int {
+	prev := make([]int, y1-y0+1)
+	cur := make([]int, y1-y0+1)
+	for i := x0; i < x1; i++ {
+		for j := y0; j < y1; j++ {
+			if d.xi[i] == d.yi[j] {
+				cur[j-y0+1] = prev[j-y0] + 1
+			} else {
+				cur[j-y0+1] = max(prev[j-y0+1], cur[j-y0])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
+
+// backward returns the lengths of the longest common subsequences of
+// x[x0:x1] and the suffixes y[y0+k:y1]
+func (d *differ) backward(x0, x1, y0, y1 int) []int {
+	prev := make([]int, y1-y0+1)
+	cur := make([]int, y1-y0+1)
+	for i := x1 - 1; i >= x0; i-- {
+		for j := y1 - 1; j >= y0; j-- {
+			if d.xi[i] == d.yi[j] {
+				cur[j-y0] = prev[j-y0+1] + 1
+			} else {
+				cur[j-y0] = max(prev[j-y0], cur[j-y0+1])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(lyrics.Normalize(s), "\n")
+}
@@ -0,0 +1,113 @@
+package diff
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Line
+	}{
+		{name: "both empty", want: []Line{}},
+		{
+			name: "created",
+			b:    "one\ntwo",
+			want: []Line{{OpInsert, "one"}, {OpInsert, "two"}},
+		},
+		{
+			name: "deleted",
+			a:    "one\ntwo",
+			want: []Line{{OpDelete, "one"}, {OpDelete, "two"}},
+		},
+		{
+			name: "unchanged",
+			a:    "one\ntwo",
+			b:    "one\ntwo",
+			want: []Line{{OpEqual, "one"}, {OpEqual, "two"}},
+		},
+		{
+			name: "edited line",
+			a:    "one\ntwo\nthree",
+			b:    "one\n2\nthree",
+			want: []Line{{OpEqual, "one"}, {OpDelete, "two"}, {OpInsert, "2"}, {OpEqual, "three"}},
+		},
+		{
+			name: "inserted and removed lines",
+			a:    "one\ntwo\nthree",
+			b:    "zero\none\nthree",
+			want: []Line{{OpInsert, "zero"}, {OpEqual, "one"}, {OpDelete, "two"}, {OpEqual, "three"}},
+		},
+		{
+			name: "line breaks are normalized",
+			a:    "one\r\ntwo",
+			b:    "one\ntwo",
+			want: []Line{{OpEqual, "one"}, {OpEqual, "two"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Lines(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinesRandom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	text := func() string {
+		lines := make([]string, rnd.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + rnd.Intn(5)))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	for range 200 {
+		a, b := text(), text()
+		lines := Lines(a, b)
+
+		var from, to []string
+		equal := 0
+		for _, l := range lines {
+			if l.Op != OpInsert {
+				from = append(from, l.Text)
+			}
+			if l.Op != OpDelete {
+				to = append(to, l.Text)
+			}
+			if l.Op == OpEqual {
+				equal++
+			}
+		}
+		if strings.Join(from, "\n") != a || strings.Join(to, "\n") != b {
+			t.Fatalf("Lines(%q, %q) = %v does not turn a into b", a, b, lines)
+		}
+		if want := lcs(splitLines(a), splitLines(b)); equal != want {
+			t.Fatalf("Lines(%q, %q) keeps %d lines, want %d", a, b, equal, want)
+		}
+	}
+}
+
+// lcs returns the length of the longest common subsequence of the lines
+func lcs(x, y []string) int {
+	n := make([][]int, len(x)+1)
+	for i := range n {
+		n[i] = make([]int, len(y)+1)
+	}
+	for i := range x {
+		for j := range y {
+			if x[i] == y[j] {
+				n[i+1][j+1] = n[i][j] + 1
+			} else {
+				n[i+1][j+1] = max(n[i][j+1], n[i+1][j])
+			}
+		}
+	}
+	return n[len(x)][len(y)]
+}
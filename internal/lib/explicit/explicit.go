@@ -0,0 +1,124 @@
+package explicit
+
+import (
+	"bufio"
+	_ "embed"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed words.txt
+var defaultWords string
+
+// Classifier tells explicit lyrics by a list of offending words
+type Classifier struct {
+	words map[string]struct{}
+	stems []string
+}
+
+// New returns the classifier of the words, a word with a trailing * matches
+// every word starting with the stem
+func New(words []string) *Classifier {
+	c := &Classifier{words: make(map[string]struct{})}
+	for _, w := range words {
+		w = strings.ToLower(strings.TrimSpace(w))
+		switch {
+		case w == "", strings.HasPrefix(w, "#"):
+		case strings.HasSuffix(w, "*"):
+			c.stems = append(c.stems, strings.TrimSuffix(w, "*"))
+		default:
+			c.words[w] = struct{}{}
+		}
+	}
+	return c
+}
+
+// Default returns the classifier of the built-in word list
+func Default() *Classifier {
+	return New(strings.Split(defaultWords, "\n"))
+}
+
+// Load returns the classifier of the word list file with a word per line,
+// lines starting with # are comments
+func Load(path string) (*Classifier, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		words = append(words, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return New(words), nil
+}
+
+// Offensive reports whether the word is on the list
+func (c *Classifier) Offensive(word string) bool {
+	word = strings.ToLower(word)
+	if _, ok := c.words[word]; ok {
+		return true
+	}
+	for _, stem := range c.stems {
+		if strings.HasPrefix(word, stem) {
+			return true
+		}
+	}
+	return false
+}
+
+// Explicit reports whether the text has an offending word
+func (c *Classifier) Explicit(text string) bool {
+	for _, w := range strings.FieldsFunc(text, isSeparator) {
+		if c.Offensive(w) {
+			return true
+		}
+	}
+	return false
+}
+
+// Mask replaces all the letters but the first one of every offending word
+// of the text with asterisks
+func (c *Classifier) Mask(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+
+	for len(text) > 0 {
+		i := strings.IndexFunc(text, func(r rune) bool { return !isSeparator(r) })
+		if i < 0 {
+			b.WriteString(text)
+			break
+		}
+		b.WriteString(text[:i])
+		text = text[i:]
+
+		end := strings.IndexFunc(text, isSeparator)
+		if end < 0 {
+			end = len(text)
+		}
+		word := text[:end]
+		text = text[end:]
+
+		if !c.Offensive(word) {
+			b.WriteString(word)
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(word)
+		b.WriteString(word[:size])
+		b.WriteString(strings.Repeat("*", utf8.RuneCountInString(word)-1))
+	}
+
+	return b.String()
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
@@ -0,0 +1,80 @@
+package explicit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMask(t *testing.T) {
+	c := New([]string{"# comment", "", "damn", " Hell ", "bitch*", "блин"})
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"clean", "Hello darkness, my old friend", "Hello darkness, my old friend"},
+		{"word", "damn it", "d*** it"},
+		{"case", "DAMN, Hell!", "D***, H***!"},
+		{"stem", "bitches and bitchy", "b****** and b*****"},
+		{"part of a word", "hello shell damnation", "hello shell damnation"},
+		{"multibyte", "блин, ну блин", "б***, ну б***"},
+		{"line breaks", "damn\r\nhell\n", "d***\r\nh***\n"},
+		{"only separators", " ,.!\n", " ,.!\n"},
+		{"empty", "", ""},
+		{"comment is not a word", "# comment", "# comment"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Mask(tt.text); got != tt.want {
+				t.Errorf("Mask() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExplicit(t *testing.T) {
+	c := New([]string{"damn", "bitch*"})
+
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"Hello darkness", false},
+		{"well, DAMN", true},
+		{"son of a bitches", true},
+		{"damnation", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := c.Explicit(tt.text); got != tt.want {
+			t.Errorf("Explicit(%q) = %t, want %t", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(path, []byte("# list\nheck\ndarn*\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Explicit("oh heck") || !c.Explicit("darned") || c.Explicit("list") {
+		t.Errorf("Load() classifier does not follow the list")
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Errorf("Load() of a missing file succeeded")
+	}
+}
+
+func TestDefault(t *testing.T) {
+	if !Default().Explicit("you bastard") {
+		t.Errorf("Default() classifier misses a listed word")
+	}
+}
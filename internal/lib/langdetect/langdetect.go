@@ -0,0 +1,207 @@
+package langdetect
+
+import (
+	"embed"
+	"math"
+	"path"
+	"strings"
+	"unicode"
+)
+
+// Undetermined is the language of texts too short or too unusual to be
+// detected
+const Undetermined = "und"
+
+// minLetters is the least number of letters of a text to detect its
+// language, scripts of syllables and ideographs need fewer of them
+const (
+	minLetters  = 12
+	minSyllabic = 4
+)
+
+// maxEvidence caps the number of distinct trigrams weighted in the
+// confidence, so long or repetitive texts do not make every guess look
+// certain
+const maxEvidence = 40
+
+// minConfidence is the least confidence of a guess, the language of texts
+// told apart worse is undetermined
+const minConfidence = 0.5
+
+//go:embed corpus/*.txt
+var corpus embed.FS
+
+// profile is the smoothed trigram log-probabilities of a language
+type profile struct {
+	lang    string
+	logProb map[string]float64
+	unseen  float64
+}
+
+// scriptLanguages are the languages told apart by their script alone
+var scriptLanguages = []struct {
+	table *unicode.RangeTable
+	lang  string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Georgian, "ka"},
+	{unicode.Armenian, "hy"},
+}
+
+// profiles are the trigram profiles of languages written in the Latin and
+// Cyrillic scripts, built from the embedded corpus
+var profiles = mustLoadProfiles()
+
+func mustLoadProfiles() []profile {
+	files, err := corpus.ReadDir("corpus")
+	if err != nil {
+		panic(err)
+	}
+
+	loaded := make([]profile, 0, len(files))
+	for _, f := range files {
+		text, err := corpus.ReadFile(path.Join("corpus", f.Name()))
+		if err != nil {
+			panic(err)
+		}
+		loaded = append(loaded, newProfile(strings.TrimSuffix(f.Name(), ".txt"), string(text)))
+	}
+	return loaded
+}
+
+func newProfile(lang, text string) profile {
+	counts := trigrams(text)
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+
+	// add-one smoothing over the seen trigrams and one more for the unseen
+	denominator := float64(total + len(counts) + 1)
+	p := profile{
+		lang:    lang,
+		logProb: make(map[string]float64, len(counts)),
+		unseen:  math.Log(1 / denominator),
+	}
+	for g, n := range counts {
+		p.logProb[g] = math.Log(float64(n+1) / denominator)
+	}
+	return p
+}
+
+// trigrams counts the letter trigrams of the words of the text, the words
+// are padded with spaces so their starts and ends make trigrams too
+func trigrams(text string) map[string]int {
+	counts := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	}) {
+		runes := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			counts[string(runes[i:i+3])]++
+		}
+	}
+	return counts
+}
+
+// Detect returns the BCP 47 tag of the language of the text and the
+// confidence of the guess from 0 to 1
+func Detect(text string) (string, float64) {
+	var letters, latin, cyrillic, han int
+	scripts := make(map[string]int)
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Han, r):
+			han++
+		default:
+			for _, s := range scriptLanguages {
+				if unicode.Is(s.table, r) {
+					scripts[s.lang]++
+					break
+				}
+			}
+		}
+	}
+
+	// japanese mixes kana with han, chinese is written in han alone
+	if scripts["ja"] > 0 {
+		scripts["ja"] += han
+	} else {
+		scripts["zh"] = han
+	}
+
+	best, bestCount := "", 0
+	for lang, n := range scripts {
+		if n > bestCount {
+			best, bestCount = lang, n
+		}
+	}
+	if bestCount > latin+cyrillic {
+		if bestCount < minSyllabic {
+			return Undetermined, 0
+		}
+		return best, float64(bestCount) / float64(letters)
+	}
+	if letters < minLetters {
+		return Undetermined, 0
+	}
+
+	return detectByTrigrams(text, float64(latin+cyrillic)/float64(letters))
+}
+
+// detectByTrigrams picks the language whose profile makes the trigrams of
+// the text most likely, the share of letters in the profiled scripts scales
+// the confidence
+func detectByTrigrams(text string, share float64) (string, float64) {
+	counts := trigrams(text)
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	if total == 0 {
+		return Undetermined, 0
+	}
+
+	scores := make([]float64, len(profiles))
+	best := 0
+	for i, p := range profiles {
+		for g, n := range counts {
+			lp, ok := p.logProb[g]
+			if !ok {
+				lp = p.unseen
+			}
+			scores[i] += float64(n) * lp
+		}
+		if scores[i] > scores[best] {
+			best = i
+		}
+	}
+
+	// the posterior of the best language with the evidence capped
+	weight := float64(min(len(counts), maxEvidence)) / float64(total)
+	var sum float64
+	for _, s := range scores {
+		sum += math.Exp((s - scores[best]) * weight)
+	}
+
+	confidence := share / sum
+	if confidence < minConfidence {
+		return Undetermined, 0
+	}
+	return profiles[best].lang, confidence
+}
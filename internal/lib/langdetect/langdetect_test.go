@@ -0,0 +1,59 @@
+package langdetect
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"english", "I can't stop thinking about you every night and every day, my heart is yours forever", "en"},
+		{"russian", "Я не могу перестать думать о тебе каждую ночь, моё сердце навсегда принадлежит тебе", "ru"},
+		{"ukrainian", "Я не можу перестати думати про тебе щоночі, моє серце назавжди належить тобі", "uk"},
+		{"bulgarian", "Не мога да спра да мисля за теб всяка нощ, сърцето ми е твое завинаги", "bg"},
+		{"german", "Ich kann nicht aufhören, jede Nacht an dich zu denken, mein Herz gehört für immer dir", "de"},
+		{"spanish", "No puedo dejar de pensar en ti cada noche, mi corazón es tuyo para siempre", "es"},
+		{"french", "Je ne peux pas m'empêcher de penser à toi chaque nuit, mon cœur est à toi pour toujours", "fr"},
+		{"italian", "Non riesco a smettere di pensare a te ogni notte, il mio cuore è tuo per sempre", "it"},
+		{"portuguese", "Não consigo parar de pensar em ti todas as noites, o meu coração é teu para sempre", "pt"},
+		{"dutch", "Ik kan niet ophouden elke nacht aan je te denken, mijn hart is voor altijd van jou", "nl"},
+		{"polish", "Nie mogę przestać myśleć o tobie każdej nocy, moje serce jest twoje na zawsze", "pl"},
+		{"swedish", "Jag kan inte sluta tänka på dig varje natt, mitt hjärta är ditt för alltid", "sv"},
+		{"turkish", "Her gece seni düşünmeden duramıyorum, kalbim sonsuza kadar senin", "tr"},
+		{"korean", "너를 생각하지 않을 수 없어", "ko"},
+		{"japanese", "君のことを毎晩考えている", "ja"},
+		{"chinese", "我每天晚上都在想你", "zh"},
+		{"greek", "Δεν μπορώ να σταματήσω να σε σκέφτομαι", "el"},
+		{"too short", "la la", Undetermined},
+		{"no letters", "1, 2, 3, 4 !!! ...", Undetermined},
+		{"empty", "", Undetermined},
+		{"few syllables", "愛", Undetermined},
+		{"no language", "xqzt vbnm kjhg wrtp zzxq plmk", Undetermined},
+		{"vocables", "oh la la la la la la", Undetermined},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, confidence := Detect(tt.text)
+			if got != tt.want {
+				t.Fatalf("Detect() = %s (%.2f), want %s", got, confidence, tt.want)
+			}
+			if got == Undetermined && confidence != 0 {
+				t.Errorf("Detect() confidence = %.2f, want 0 for %s", confidence, Undetermined)
+			}
+			if got != Undetermined && (confidence <= 0 || confidence > 1) {
+				t.Errorf("Detect() confidence = %.2f, want within (0, 1]", confidence)
+			}
+		})
+	}
+}
+
+func TestDetectConfidence(t *testing.T) {
+	// a text of one language is told apart with more confidence than a mix
+	// of two
+	_, pure := Detect("Ich kann nicht aufhören, jede Nacht an dich zu denken, mein Herz gehört für immer dir")
+	_, mixed := Detect("Ich kann nicht aufhören my heart is yours forever")
+	if mixed >= pure {
+		t.Errorf("confidence of a mixed text = %.2f, want below %.2f", mixed, pure)
+	}
+}
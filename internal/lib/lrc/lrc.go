@@ -0,0 +1,170 @@
+package lrc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/foreground-eclipse/song-library/internal/lib/lyrics"
+)
+
+var ErrNoLines = errors.New("no timed lines in lyrics")
+
+// Line is a line of lyrics shown from its start time of the playback
+type Line struct {
+	Start time.Duration
+	Text  string
+}
+
+// MarshalJSON renders the start time of the line in milliseconds
+func (l Line) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		StartMS int64  `json:"start_ms"`
+		Text    string `json:"text"`
+	}{l.Start.Milliseconds(), l.Text})
+}
+
+// Meta holds the ID tags of an LRC file
+type Meta struct {
+	Artist string
+	Title  string
+}
+
+var (
+	timeTag = regexp.MustCompile(`^\[(\d{1,3}):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+	idTag   = regexp.MustCompile(`^\[([a-zA-Z#]+):(.*)\]$`)
+)
+
+// Parse parses lyrics in the LRC format. Lines with several time tags are
+// repeated at each of them and the [offset] tag shifts all of them.
+func Parse(text string) ([]Line, Meta, error) {
+	var meta Meta
+	var offset time.Duration
+	lines := make([]Line, 0)
+
+	for n, raw := range strings.Split(lyrics.Normalize(text), "\n") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		starts := make([]time.Duration, 0, 1)
+		for {
+			m := timeTag.FindStringSubmatch(raw)
+			if m == nil {
+				break
+			}
+			start, err := parseTime(m[1], m[2], m[3])
+			if err != nil {
+				return nil, meta, fmt.Errorf("line %d: %w", n+1, err)
+			}
+			starts = append(starts, start)
+			raw = raw[len(m[0]):]
+		}
+
+		if len(starts) == 0 {
+			if m := idTag.FindStringSubmatch(raw); m != nil {
+				value := strings.TrimSpace(m[2])
+				switch strings.ToLower(m[1]) {
+				case "ar":
+					meta.Artist = value
+				case "ti":
+					meta.Title = value
+				case "offset":
+					ms, err := strconv.Atoi(value)
+					if err != nil {
+						return nil, meta, fmt.Errorf("line %d: invalid offset %q", n+1, value)
+					}
+					// a positive offset shows the lyrics sooner
+					offset = -time.Duration(ms) * time.Millisecond
+				}
+			}
+			continue
+		}
+
+		for _, start := range starts {
+			lines = append(lines, Line{Start: start, Text: strings.TrimSpace(raw)})
+		}
+	}
+
+	if len(lines) == 0 {
+		return nil, meta, ErrNoLines
+	}
+
+	for i := range lines {
+		lines[i].Start = max(lines[i].Start+offset, 0)
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].Start < lines[j].Start })
+
+	return lines, meta, nil
+}
+
+func parseTime(min, sec, frac string) (time.Duration, error) {
+	m, _ := strconv.Atoi(min)
+	s, _ := strconv.Atoi(sec)
+	if s >= 60 {
+		return 0, fmt.Errorf("invalid time tag %s:%s", min, sec)
+	}
+
+	d := time.Duration(m)*time.Minute + time.Duration(s)*time.Second
+	if frac != "" {
+		// fractions are hundredths in the common form and milliseconds in the extended one
+		f, _ := strconv.Atoi(frac)
+		for i := len(frac); i < 3; i++ {
+			f *= 10
+		}
+		d += time.Duration(f) * time.Millisecond
+	}
+
+	return d, nil
+}
+
+// Format renders the lines in the LRC format
+func Format(lines []Line, meta Meta) string {
+	var b strings.Builder
+	if meta.Artist != "" {
+		fmt.Fprintf(&b, "[ar:%s]\n", meta.Artist)
+	}
+	if meta.Title != "" {
+		fmt.Fprintf(&b, "[ti:%s]\n", meta.Title)
+	}
+	for _, l := range lines {
+		cs := l.Start.Milliseconds() / 10
+		fmt.Fprintf(&b, "[%02d:%02d.%02d]%s\n", cs/6000, cs/100%60, cs%100, l.Text)
+	}
+	return b.String()
+}
+
+// FormatVTT renders the lines as WebVTT cues, each line is shown until the
+// next one starts and the last one for the given duration
+func FormatVTT(lines []Line, last time.Duration) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+	for i, l := range lines {
+		if l.Text == "" {
+			continue
+		}
+		end := l.Start + last
+		if i+1 < len(lines) {
+			end = lines[i+1].Start
+		}
+		fmt.Fprintf(&b, "\n%d\n%s --> %s\n%s\n", i+1, vttTime(l.Start), vttTime(end), l.Text)
+	}
+	return b.String()
+}
+
+func vttTime(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// At returns the index of the line active at the playback offset,
+// -1 before the first line
+func At(lines []Line, offset time.Duration) int {
+	return sort.Search(len(lines), func(i int) bool { return lines[i].Start > offset }) - 1
+}
@@ -0,0 +1,138 @@
+package lrc
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func ms(n int) time.Duration { return time.Duration(n) * time.Millisecond }
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		want     []Line
+		wantMeta Meta
+		wantErr  bool
+	}{
+		{
+			name: "hundredths",
+			text: "[00:12.34]First line\n[00:15.00]Second line",
+			want: []Line{{ms(12340), "First line"}, {ms(15000), "Second line"}},
+		},
+		{
+			name: "milliseconds and no fraction",
+			text: "[01:02.345]First\n[01:03]Second",
+			want: []Line{{ms(62345), "First"}, {ms(63000), "Second"}},
+		},
+		{
+			name:     "id tags",
+			text:     "[ar: Muse ]\n[ti:Supermassive Black Hole]\n[al:Black Holes]\n[00:01.00]Oh baby",
+			want:     []Line{{ms(1000), "Oh baby"}},
+			wantMeta: Meta{Artist: "Muse", Title: "Supermassive Black Hole"},
+		},
+		{
+			name: "repeated line",
+			text: "[00:10.00][00:30.00]Chorus\n[00:20.00]Verse",
+			want: []Line{{ms(10000), "Chorus"}, {ms(20000), "Verse"}, {ms(30000), "Chorus"}},
+		},
+		{
+			name: "positive offset",
+			text: "[offset:+500]\n[00:00.20]Sooner\n[00:02.00]Later",
+			want: []Line{{0, "Sooner"}, {ms(1500), "Later"}},
+		},
+		{
+			name: "negative offset",
+			text: "[offset:-500]\n[00:01.00]Later",
+			want: []Line{{ms(1500), "Later"}},
+		},
+		{
+			name: "carriage returns and blank lines",
+			text: "[00:01.00]One\r\r\n[00:02.00]\r[00:03.00]Three",
+			want: []Line{{ms(1000), "One"}, {ms(2000), ""}, {ms(3000), "Three"}},
+		},
+		{name: "no timed lines", text: "[ar:Muse]\nplain text", wantErr: true},
+		{name: "invalid seconds", text: "[00:75.00]Line", wantErr: true},
+		{name: "invalid offset", text: "[offset:soon]\n[00:01.00]Line", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, meta, err := Parse(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, want error %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+			if meta != tt.wantMeta {
+				t.Errorf("Parse() meta = %+v, want %+v", meta, tt.wantMeta)
+			}
+		})
+	}
+}
+
+func TestParseNoLines(t *testing.T) {
+	if _, _, err := Parse("[ar:Muse]\nplain text"); !errors.Is(err, ErrNoLines) {
+		t.Errorf("Parse() error = %v, want %v", err, ErrNoLines)
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	lines := []Line{{ms(0), "Intro"}, {ms(12340), "First line"}, {ms(61990), ""}, {ms(3723450), "Late"}}
+	meta := Meta{Artist: "Muse", Title: "Starlight"}
+
+	text := Format(lines, meta)
+	const want = "[ar:Muse]\n[ti:Starlight]\n[00:00.00]Intro\n[00:12.34]First line\n[01:01.99]\n[62:03.45]Late\n"
+	if text != want {
+		t.Fatalf("Format() = %q, want %q", text, want)
+	}
+
+	parsed, parsedMeta, err := Parse(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, lines) || parsedMeta != meta {
+		t.Errorf("Parse(Format()) = %v, %+v, want %v, %+v", parsed, parsedMeta, lines, meta)
+	}
+}
+
+func TestFormatVTT(t *testing.T) {
+	lines := []Line{{ms(1000), "One"}, {ms(2500), ""}, {ms(4000), "Two"}}
+
+	const want = "WEBVTT\n\n1\n00:00:01.000 --> 00:00:02.500\nOne\n\n3\n00:00:04.000 --> 00:00:09.000\nTwo\n"
+	if got := FormatVTT(lines, 5*time.Second); got != want {
+		t.Errorf("FormatVTT() = %q, want %q", got, want)
+	}
+}
+
+func TestAt(t *testing.T) {
+	lines := []Line{{ms(1000), "One"}, {ms(2000), "Two"}, {ms(2000), "Also two"}, {ms(5000), "Three"}}
+
+	tests := []struct {
+		offset time.Duration
+		want   int
+	}{
+		{0, -1},
+		{ms(999), -1},
+		{ms(1000), 0},
+		{ms(1999), 0},
+		{ms(2000), 2},
+		{ms(4999), 2},
+		{ms(5000), 3},
+		{time.Hour, 3},
+	}
+	for _, tt := range tests {
+		if got := At(lines, tt.offset); got != tt.want {
+			t.Errorf("At(%v) = %d, want %d", tt.offset, got, tt.want)
+		}
+	}
+
+	if got := At(nil, time.Second); got != -1 {
+		t.Errorf("At() of no lines = %d, want -1", got)
+	}
+}
@@ -0,0 +1,167 @@
+package lyrics
+
+import "strings"
+
+// Normalize converts the line breaks of the lyrics to "\n"
+func Normalize(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "\n")
+}
+
+// Verses splits the lyrics into verses separated by blank lines or section
+// markers like "[Chorus]", trimming the surrounding whitespace of each verse
+func Verses(text string) []string {
+	verses := make([]string, 0)
+	verse := make([]string, 0)
+
+	flush := func() {
+		if len(verse) > 0 {
+			verses = append(verses, strings.Join(verse, "\n"))
+			verse = verse[:0]
+		}
+	}
+
+	for _, line := range strings.Split(Normalize(text), "\n") {
+		line = strings.TrimRight(line, " \t")
+		trimmed := strings.TrimSpace(line)
+		if _, ok := marker(trimmed); ok || trimmed == "" {
+			flush()
+			continue
+		}
+		verse = append(verse, line)
+	}
+	flush()
+
+	return verses
+}
+
+// Section types of structured lyrics
+const (
+	SectionIntro     = "intro"
+	SectionVerse     = "verse"
+	SectionPreChorus = "pre-chorus"
+	SectionChorus    = "chorus"
+	SectionBridge    = "bridge"
+	SectionHook      = "hook"
+	SectionOutro     = "outro"
+	SectionOther     = "other"
+)
+
+// sectionTypes maps the lowercased first word of a section marker to its type
+var sectionTypes = map[string]string{
+	"intro":      SectionIntro,
+	"verse":      SectionVerse,
+	"куплет":     SectionVerse,
+	"pre-chorus": SectionPreChorus,
+	"prechorus":  SectionPreChorus,
+	"chorus":     SectionChorus,
+	"refrain":    SectionChorus,
+	"припев":     SectionChorus,
+	"bridge":     SectionBridge,
+	"бридж":      SectionBridge,
+	"hook":       SectionHook,
+	"outro":      SectionOutro,
+	"аутро":      SectionOutro,
+	"интро":      SectionIntro,
+}
+
+// Section is an ordered part of structured lyrics. A section repeating an
+// earlier one has no text of its own and refers to it by RepeatOf.
+type Section struct {
+	Position int    `json:"position"`
+	Type     string `json:"type"`
+	Label    string `json:"label"`
+	Text     string `json:"text,omitempty"`
+	RepeatOf *int   `json:"repeat_of,omitempty"`
+}
+
+// Sections parses the lyrics into sections. Sections start at markers like
+// "[Chorus]" or "[Verse 2]" and at blank lines, unmarked sections are verses.
+// A chorus with the same text as an earlier one, or a marker without text,
+// becomes a repeat of the earlier section of the same label or type.
+func Sections(text string) []Section {
+	sections := make([]Section, 0)
+	var current *Section
+	lines := make([]string, 0)
+
+	flush := func() {
+		if current == nil && len(lines) == 0 {
+			return
+		}
+		if current == nil {
+			current = &Section{Type: SectionVerse}
+		}
+		current.Position = len(sections) + 1
+		current.Text = strings.Join(lines, "\n")
+		sections = append(sections, *current)
+		current = nil
+		lines = lines[:0]
+	}
+
+	for _, line := range strings.Split(Normalize(text), "\n") {
+		line = strings.TrimRight(line, " \t")
+		trimmed := strings.TrimSpace(line)
+
+		if label, ok := marker(trimmed); ok {
+			flush()
+			current = &Section{Type: sectionType(label), Label: label}
+			continue
+		}
+		if trimmed == "" {
+			// a blank line right after a marker does not end its section
+			if len(lines) > 0 {
+				flush()
+			}
+			continue
+		}
+		lines = append(lines, line)
+	}
+	flush()
+
+	return dedupe(sections)
+}
+
+// marker returns the label of a section marker line like "[Chorus x2]"
+func marker(line string) (string, bool) {
+	if len(line) < 3 || line[0] != '[' || line[len(line)-1] != ']' {
+		return "", false
+	}
+	label := strings.TrimSpace(line[1 : len(line)-1])
+	return label, label != ""
+}
+
+func sectionType(label string) string {
+	word := strings.ToLower(label)
+	if i := strings.IndexAny(word, " :0123456789"); i > 0 {
+		word = word[:i]
+	}
+	if t, ok := sectionTypes[word]; ok {
+		return t
+	}
+	return SectionOther
+}
+
+// dedupe turns choruses and hooks repeating an earlier section, and empty
+// marked sections, into repeats of that section
+func dedupe(sections []Section) []Section {
+	for i := range sections {
+		s := &sections[i]
+		for j := i - 1; j >= 0; j-- {
+			prev := sections[j]
+			if prev.RepeatOf != nil {
+				continue
+			}
+			sameText := s.Text != "" && s.Text == prev.Text &&
+				(s.Type == SectionChorus || s.Type == SectionHook || s.Type == prev.Type)
+			sameLabel := s.Text == "" && s.Label != "" &&
+				(strings.EqualFold(prev.Label, s.Label) || prev.Type == s.Type)
+			if sameText || sameLabel {
+				position := prev.Position
+				s.RepeatOf = &position
+				s.Text = ""
+				break
+			}
+		}
+	}
+	return sections
+}
@@ -0,0 +1,50 @@
+package lyrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"one\ntwo", "one\ntwo"},
+		{"one\r\ntwo", "one\ntwo"},
+		{"one\rtwo", "one\ntwo"},
+		{"one\r\n\r\ntwo\r", "one\n\ntwo\n"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.text); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestVerses(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"empty", "", []string{}},
+		{"blank", "\n \n\t\n", []string{}},
+		{"one verse", "one\ntwo", []string{"one\ntwo"}},
+		{"blank line", "one\ntwo\n\nthree", []string{"one\ntwo", "three"}},
+		{"several blank lines", "one\n\n\n\ntwo", []string{"one", "two"}},
+		{"whitespace line", "one\n  \t\ntwo", []string{"one", "two"}},
+		{"windows breaks", "one\r\ntwo\r\n\r\nthree\r\n", []string{"one\ntwo", "three"}},
+		{"classic mac breaks", "one\r\rtwo", []string{"one", "two"}},
+		{"trailing spaces", "one  \ntwo\t", []string{"one\ntwo"}},
+		{"indentation kept", "  one\n  two", []string{"  one\n  two"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verses(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Verses() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
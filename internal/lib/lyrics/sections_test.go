@@ -0,0 +1,98 @@
+package lyrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVersesMarkers(t *testing.T) {
+	text := "[Verse 1]\none\ntwo\n[Chorus]\nla la\n\n[Chorus]"
+	want := []string{"one\ntwo", "la la"}
+	if got := Verses(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("Verses() = %q, want %q", got, want)
+	}
+}
+
+func TestSections(t *testing.T) {
+	repeat := func(position int) *int { return &position }
+
+	tests := []struct {
+		name string
+		text string
+		want []Section
+	}{
+		{
+			name: "unmarked verses",
+			text: "one\ntwo\n\nthree",
+			want: []Section{
+				{Position: 1, Type: SectionVerse, Text: "one\ntwo"},
+				{Position: 2, Type: SectionVerse, Text: "three"},
+			},
+		},
+		{
+			name: "marked sections",
+			text: "[Intro]\noh\n\n[Verse 1]\none\n[Pre-Chorus]\nhere it comes\n[Bridge]\nbridge\n[Outro]\nbye",
+			want: []Section{
+				{Position: 1, Type: SectionIntro, Label: "Intro", Text: "oh"},
+				{Position: 2, Type: SectionVerse, Label: "Verse 1", Text: "one"},
+				{Position: 3, Type: SectionPreChorus, Label: "Pre-Chorus", Text: "here it comes"},
+				{Position: 4, Type: SectionBridge, Label: "Bridge", Text: "bridge"},
+				{Position: 5, Type: SectionOutro, Label: "Outro", Text: "bye"},
+			},
+		},
+		{
+			name: "russian markers",
+			text: "[Куплет 1]\nраз\n[Припев]\nля-ля",
+			want: []Section{
+				{Position: 1, Type: SectionVerse, Label: "Куплет 1", Text: "раз"},
+				{Position: 2, Type: SectionChorus, Label: "Припев", Text: "ля-ля"},
+			},
+		},
+		{
+			name: "blank line after a marker",
+			text: "[Chorus]\n\nla la\nla la",
+			want: []Section{
+				{Position: 1, Type: SectionChorus, Label: "Chorus", Text: "la la\nla la"},
+			},
+		},
+		{
+			name: "repeated chorus text",
+			text: "[Chorus]\nla la\n[Verse 2]\ntwo\n[Chorus]\nla la",
+			want: []Section{
+				{Position: 1, Type: SectionChorus, Label: "Chorus", Text: "la la"},
+				{Position: 2, Type: SectionVerse, Label: "Verse 2", Text: "two"},
+				{Position: 3, Type: SectionChorus, Label: "Chorus", RepeatOf: repeat(1)},
+			},
+		},
+		{
+			name: "chorus marker without text",
+			text: "[Chorus x2]\nla la\n[Verse]\ntwo\n[Chorus x2]",
+			want: []Section{
+				{Position: 1, Type: SectionChorus, Label: "Chorus x2", Text: "la la"},
+				{Position: 2, Type: SectionVerse, Label: "Verse", Text: "two"},
+				{Position: 3, Type: SectionChorus, Label: "Chorus x2", RepeatOf: repeat(1)},
+			},
+		},
+		{
+			name: "unknown marker",
+			text: "[Spoken]\nhey",
+			want: []Section{
+				{Position: 1, Type: SectionOther, Label: "Spoken", Text: "hey"},
+			},
+		},
+		{
+			name: "brackets within a line",
+			text: "[not a marker] but a line",
+			want: []Section{
+				{Position: 1, Type: SectionVerse, Text: "[not a marker] but a line"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sections(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Sections() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package releasedate
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ISO is the layout release dates are stored and returned in
+const ISO = "2006-01-02"
+
+var ErrInvalidDate = errors.New("invalid release date")
+
+// layouts are the accepted input formats of a release date
+var layouts = []string{
+	ISO,
+	"2006/01/02",
+	"02.01.2006",
+	"2.1.2006",
+	"02/01/2006",
+	"2/1/2006",
+	"2 January 2006",
+	"2 Jan 2006",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"January 2006",
+	"2006-01",
+	time.RFC3339,
+}
+
+// Parse parses a release date in one of the common formats and returns it
+// in the ISO format, a bare year means the first day of the year
+func Parse(s string) (string, error) {
+	t, err := ParseTime(s)
+	if err != nil {
+		return "", err
+	}
+	return t.Format(ISO), nil
+}
+
+// ParseTime parses a release date in one of the common formats, there was
+// no year 0
+func ParseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+
+	if len(s) == 4 {
+		if year, err := strconv.Atoi(s); err == nil && year > 0 {
+			return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC), nil
+		}
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil && t.Year() > 0 {
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidDate, s)
+}
+
+// Range is a half-open range of release dates, zero bounds are open
+type Range struct {
+	From  time.Time
+	Until time.Time
+}
+
+// Year returns the range of release dates of the year
+func Year(year int) Range {
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return Range{From: from, Until: from.AddDate(1, 0, 0)}
+}
+
+// Decade returns the range of release dates of the decade, given either as
+// its first year ("1990") or in the short form ("90s", "1990s")
+func Decade(s string) (Range, error) {
+	s = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), "s")
+
+	year, err := strconv.Atoi(s)
+	if err != nil || year < 0 {
+		return Range{}, fmt.Errorf("invalid decade: %q", s)
+	}
+	if len(s) == 2 {
+		// two digit decades are of the 20th century up to the 20s
+		if year >= 30 {
+			year += 1900
+		} else {
+			year += 2000
+		}
+	}
+	if year == 0 || year%10 != 0 {
+		return Range{}, fmt.Errorf("invalid decade: %q", s)
+	}
+
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return Range{From: from, Until: from.AddDate(10, 0, 0)}, nil
+}
+
+// Intersect returns the release dates within both ranges
+func (r Range) Intersect(o Range) Range {
+	if r.From.IsZero() || o.From.After(r.From) {
+		r.From = o.From
+	}
+	if r.Until.IsZero() || (!o.Until.IsZero() && o.Until.Before(r.Until)) {
+		r.Until = o.Until
+	}
+	return r
+}
@@ -0,0 +1,113 @@
+package releasedate
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "2006-07-16", want: "2006-07-16"},
+		{in: " 2006-07-16 ", want: "2006-07-16"},
+		{in: "2006/07/16", want: "2006-07-16"},
+		{in: "16.07.2006", want: "2006-07-16"},
+		{in: "6.7.2006", want: "2006-07-06"},
+		{in: "16/07/2006", want: "2006-07-16"},
+		{in: "16 July 2006", want: "2006-07-16"},
+		{in: "16 Jul 2006", want: "2006-07-16"},
+		{in: "July 16, 2006", want: "2006-07-16"},
+		{in: "Jul 16, 2006", want: "2006-07-16"},
+		{in: "July 2006", want: "2006-07-01"},
+		{in: "2006-07", want: "2006-07-01"},
+		{in: "2006", want: "2006-01-01"},
+		{in: "2006-07-16T23:30:00+03:00", want: "2006-07-16"},
+		{in: "", wantErr: true},
+		{in: "someday", wantErr: true},
+		{in: "2006-13-01", wantErr: true},
+		{in: "30.02.2006", wantErr: true},
+		{in: "07/16/2006", wantErr: true},
+		{in: "0000", wantErr: true},
+		{in: "0000-01-01", wantErr: true},
+		{in: "1 January 0000", wantErr: true},
+		{in: "0001", want: "0001-01-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Parse(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, want error %t", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidDate) {
+				t.Errorf("Parse() error = %v, want %v", err, ErrInvalidDate)
+			}
+			if got != tt.want {
+				t.Errorf("Parse() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecade(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Range
+		wantErr bool
+	}{
+		{in: "1990", want: Range{date(1990, 1, 1), date(2000, 1, 1)}},
+		{in: "1990s", want: Range{date(1990, 1, 1), date(2000, 1, 1)}},
+		{in: "90s", want: Range{date(1990, 1, 1), date(2000, 1, 1)}},
+		{in: "30s", want: Range{date(1930, 1, 1), date(1940, 1, 1)}},
+		{in: "20s", want: Range{date(2020, 1, 1), date(2030, 1, 1)}},
+		{in: "00S", want: Range{date(2000, 1, 1), date(2010, 1, 1)}},
+		{in: "1995", wantErr: true},
+		{in: "95s", wantErr: true},
+		{in: "-10", wantErr: true},
+		{in: "0s", wantErr: true},
+		{in: "0000s", wantErr: true},
+		{in: "nineties", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Decade(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decade() error = %v, want error %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Decade() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntersect(t *testing.T) {
+	nineties := Range{date(1990, 1, 1), date(2000, 1, 1)}
+
+	tests := []struct {
+		name string
+		a, b Range
+		want Range
+	}{
+		{"open ranges", Range{}, Range{}, Range{}},
+		{"with an open range", nineties, Range{}, nineties},
+		{"open with a range", Range{}, nineties, nineties},
+		{"year of the decade", nineties, Year(1995), Year(1995)},
+		{"overlap", nineties, Range{From: date(1998, 6, 1)}, Range{date(1998, 6, 1), date(2000, 1, 1)}},
+		{"upper bound", Range{From: date(1985, 1, 1)}, Range{Until: date(1991, 1, 1)}, Range{date(1985, 1, 1), date(1991, 1, 1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Intersect(tt.b); got != tt.want {
+				t.Errorf("Intersect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package rhyme
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Names of common rhyme schemes of quatrains and couplets
+var names = map[string]string{
+	"AA":   "couplet",
+	"AABB": "couplets",
+	"ABAB": "alternate",
+	"ABBA": "enclosed",
+	"AAAA": "monorhyme",
+	"ABCB": "ballad",
+	"AABA": "rubaiyat",
+	"ABAC": "alternate half",
+}
+
+// Line is a line of a verse with the label of its rhyme
+type Line struct {
+	Text string `json:"text"`
+	// Label is the letter shared by rhyming lines, "-" for a line
+	// without words
+	Label string `json:"label"`
+	// Ending is the sound the rhyme is detected by
+	Ending string `json:"ending"`
+}
+
+// Verse is the rhyme scheme of a verse
+type Verse struct {
+	Index  int    `json:"index"`
+	Scheme string `json:"scheme"`
+	// Name is the name of a common scheme, empty for others
+	Name  string `json:"name,omitempty"`
+	Lines []Line `json:"lines"`
+}
+
+// Scheme labels the lines of the verse with letters, rhyming lines share
+// a letter
+func Scheme(index int, verse string) Verse {
+	v := Verse{Index: index, Lines: make([]Line, 0)}
+
+	var scheme strings.Builder
+	next := 'A'
+	for _, text := range strings.Split(verse, "\n") {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+
+		line := Line{Text: text, Label: "-", Ending: Ending(lastWord(text))}
+		if line.Ending != "" {
+			for _, prev := range v.Lines {
+				if prev.Ending != "" && rhymes(prev.Ending, line.Ending) {
+					line.Label = prev.Label
+					break
+				}
+			}
+			if line.Label == "-" {
+				line.Label = string(next)
+				if next < 'Z' {
+					next++
+				}
+			}
+		}
+
+		v.Lines = append(v.Lines, line)
+		scheme.WriteString(line.Label)
+	}
+
+	v.Scheme = scheme.String()
+	v.Name = names[v.Scheme]
+	return v
+}
+
+// Ending returns the rhyming sound of the word: its last vowel sound with
+// the consonants following it, spelled phonetically where English spelling
+// is ambiguous
+func Ending(word string) string {
+	w := []rune(normalize(word))
+	if len(w) == 0 {
+		return ""
+	}
+
+	// the last vowel group and the consonants after it
+	end := len(w)
+	i := end - 1
+	for i >= 0 && !isVowel(w[i]) {
+		i--
+	}
+	if i < 0 {
+		return string(w)
+	}
+	for i > 0 && isVowel(w[i-1]) {
+		i--
+	}
+
+	return string(w[i:end])
+}
+
+// rhymes reports whether the endings rhyme: they sound the same or share
+// the last three letters
+func rhymes(a, b string) bool {
+	if a == b {
+		return true
+	}
+	x, y := []rune(a), []rune(b)
+	return len(x) >= 3 && len(y) >= 3 && string(x[len(x)-3:]) == string(y[len(y)-3:])
+}
+
+// spellings maps endings of English words to their sound
+var spellings = []struct{ suffix, sound string }{
+	{"ight", "ite"},
+	{"igh", "i"},
+	{"tion", "shun"},
+	{"sion", "shun"},
+	{"ough", "o"},
+	{"eigh", "ay"},
+	{"ey", "ee"},
+	{"ea", "ee"},
+	{"ie", "ee"},
+	{"ue", "oo"},
+	{"ew", "oo"},
+	{"ou", "oo"},
+	{"ck", "k"},
+	{"ph", "f"},
+}
+
+// normalize lowercases the word, keeps its letters and respells its ending
+// the way it sounds
+func normalize(word string) string {
+	var b strings.Builder
+	var prev rune
+	for _, r := range strings.ToLower(word) {
+		if !unicode.IsLetter(r) || r == prev {
+			// doubled letters sound as one
+			continue
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	w := b.String()
+
+	for _, s := range spellings {
+		if strings.HasSuffix(w, s.suffix) {
+			w = strings.TrimSuffix(w, s.suffix) + s.sound
+			break
+		}
+	}
+
+	runes := []rune(w)
+	n := len(runes)
+	switch {
+	// a final y is a vowel: long i in one syllable words, ee in others
+	case n > 1 && runes[n-1] == 'y' && !isVowel(runes[n-2]):
+		if syllables(runes[:n-1]) == 0 {
+			runes[n-1] = 'i'
+		} else {
+			runes = append(runes[:n-1], 'e', 'e')
+		}
+	// a silent final e makes the vowel before the consonant long
+	case n > 3 && runes[n-1] == 'e' && !isVowel(runes[n-2]) && isVowel(runes[n-3]) && !isVowel(runes[n-4]):
+		runes = append(runes[:n-2], ':', runes[n-2])
+	}
+
+	// y before a vowel is a consonant, between consonants it sounds like i
+	if len(runes) > 1 && runes[0] == 'y' && isVowel(runes[1]) {
+		runes[0] = 'j'
+	}
+	for i := 1; i < len(runes); i++ {
+		if runes[i] == 'y' && !isVowel(runes[i-1]) {
+			runes[i] = 'i'
+		}
+	}
+
+	return string(runes)
+}
+
+func syllables(w []rune) int {
+	n := 0
+	for i, r := range w {
+		if isVowel(r) && (i == 0 || !isVowel(w[i-1])) {
+			n++
+		}
+	}
+	return n
+}
+
+func lastWord(line string) string {
+	words := strings.FieldsFunc(line, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	if len(words) == 0 {
+		return ""
+	}
+	return words[len(words)-1]
+}
+
+// isVowel reports whether the letter is a vowel of the Latin or Cyrillic
+// alphabet, the long vowel mark counts as a part of the vowel
+func isVowel(r rune) bool {
+	return strings.ContainsRune("aeiouyàáâãäåèéêëìíîïòóôõöøùúûüýÿæœ:аеёиоуыэюяіїє", r)
+}
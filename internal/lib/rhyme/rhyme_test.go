@@ -0,0 +1,103 @@
+package rhyme
+
+import "testing"
+
+func TestEnding(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"night", "i:t"},
+		{"Light!", "i:t"},
+		{"time", "i:m"},
+		{"rhyme", "i:m"},
+		{"fly", "i"},
+		{"happy", "ee"},
+		{"sea", "ee"},
+		{"nation", "un"},
+		{"back", "ak"},
+		{"hmm", "hm"},
+		{"ночь", "очь"},
+		{"", ""},
+		{"123", ""},
+	}
+	for _, tt := range tests {
+		if got := Ending(tt.word); got != tt.want {
+			t.Errorf("Ending(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestScheme(t *testing.T) {
+	tests := []struct {
+		name       string
+		verse      string
+		wantScheme string
+		wantName   string
+	}{
+		{
+			name:       "couplet",
+			verse:      "I walk alone tonight\nAnd hold the city light",
+			wantScheme: "AA",
+			wantName:   "couplet",
+		},
+		{
+			name:       "alternate",
+			verse:      "The stars are in the sky\nWe dance until the night\nAnd then we fly\nInto the light",
+			wantScheme: "ABAB",
+			wantName:   "alternate",
+		},
+		{
+			name:       "couplets",
+			verse:      "It is the time\nFor every rhyme\nI saw a cat\nIn a hat",
+			wantScheme: "AABB",
+			wantName:   "couplets",
+		},
+		{
+			name:       "ballad",
+			verse:      "Hold my hand\nUnder the sea\nWalk the road\nAnd drink the tea",
+			wantScheme: "ABCB",
+			wantName:   "ballad",
+		},
+		{
+			name:       "uncommon scheme",
+			verse:      "Sun\nMoon\nStar",
+			wantScheme: "ABC",
+		},
+		{
+			name:       "line without words",
+			verse:      "Fly away tonight\n...\nInto the light",
+			wantScheme: "A-A",
+		},
+		{
+			name:       "blank lines and spaces",
+			verse:      "  Call my name  \n\n  Feel the same\n",
+			wantScheme: "AA",
+			wantName:   "couplet",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := Scheme(3, tt.verse)
+			if v.Index != 3 {
+				t.Errorf("Scheme() index = %d, want 3", v.Index)
+			}
+			if v.Scheme != tt.wantScheme {
+				t.Errorf("Scheme() = %q, want %q", v.Scheme, tt.wantScheme)
+			}
+			if v.Name != tt.wantName {
+				t.Errorf("Scheme() name = %q, want %q", v.Name, tt.wantName)
+			}
+			if len(v.Lines) != len(tt.wantScheme) {
+				t.Errorf("Scheme() has %d lines, want %d", len(v.Lines), len(tt.wantScheme))
+			}
+		})
+	}
+}
+
+func TestSchemeEmpty(t *testing.T) {
+	v := Scheme(1, "")
+	if v.Scheme != "" || v.Lines == nil || len(v.Lines) != 0 {
+		t.Errorf("Scheme() of an empty verse = %+v, want no lines", v)
+	}
+}
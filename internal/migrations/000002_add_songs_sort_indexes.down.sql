@@ -0,0 +1,5 @@
+BEGIN;
+DROP INDEX IF EXISTS songs_group_id_idx;
+DROP INDEX IF EXISTS songs_song_id_idx;
+DROP INDEX IF EXISTS songs_release_date_id_idx;
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+DROP INDEX IF EXISTS songs_search_vector_idx;
+ALTER TABLE songs DROP COLUMN IF EXISTS search_vector;
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', coalesce("group", '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(song, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce("text", '')), 'B')
+    ) STORED;
+CREATE INDEX IF NOT EXISTS songs_search_vector_idx ON songs USING GIN (search_vector);
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+DROP INDEX IF EXISTS songs_uuid_idx;
+ALTER TABLE songs DROP COLUMN IF EXISTS uuid;
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS uuid UUID NOT NULL DEFAULT gen_random_uuid();
+CREATE UNIQUE INDEX IF NOT EXISTS songs_uuid_idx ON songs (uuid);
+COMMIT;
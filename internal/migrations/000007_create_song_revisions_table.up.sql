@@ -0,0 +1,21 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS song_revisions (
+    id SERIAL PRIMARY KEY,
+    song_id UUID NOT NULL,
+    version INTEGER NOT NULL,
+    operation VARCHAR(16) NOT NULL,
+    "group" VARCHAR(255) NOT NULL,
+    song VARCHAR(255) NOT NULL,
+    release_date VARCHAR(255) NOT NULL,
+    "text" TEXT NOT NULL,
+    link VARCHAR(255) NOT NULL,
+    author VARCHAR(255) NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (song_id, version)
+);
+
+INSERT INTO song_revisions (song_id, version, operation, "group", song, release_date, "text", link, author)
+SELECT uuid, version, 'create', "group", song, release_date, "text", link, 'migration'
+FROM songs
+ON CONFLICT DO NOTHING;
+COMMIT;
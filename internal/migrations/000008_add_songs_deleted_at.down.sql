@@ -0,0 +1,5 @@
+BEGIN;
+DELETE FROM songs WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS songs_deleted_at_idx;
+ALTER TABLE songs DROP COLUMN IF EXISTS deleted_at;
+COMMIT;
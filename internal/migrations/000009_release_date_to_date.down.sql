@@ -0,0 +1,6 @@
+BEGIN;
+DROP INDEX IF EXISTS songs_release_date_id_idx;
+ALTER TABLE songs ALTER COLUMN release_date TYPE VARCHAR(255) USING coalesce(to_char(release_date, 'YYYY-MM-DD'), '');
+ALTER TABLE songs ALTER COLUMN release_date SET NOT NULL;
+CREATE INDEX IF NOT EXISTS songs_release_date_id_idx ON songs (release_date, id);
+COMMIT;
@@ -0,0 +1,82 @@
+BEGIN;
+-- month_number returns the number of the month by its English name or its
+-- three letter abbreviation
+CREATE FUNCTION pg_temp.month_number(name TEXT) RETURNS INT AS $$
+    SELECT coalesce(
+        array_position(ARRAY['january', 'february', 'march', 'april', 'may', 'june', 'july',
+            'august', 'september', 'october', 'november', 'december'], lower(name)),
+        array_position(ARRAY['jan', 'feb', 'mar', 'apr', 'may', 'jun', 'jul',
+            'aug', 'sep', 'oct', 'nov', 'dec'], lower(name)))
+$$ LANGUAGE sql IMMUTABLE;
+
+-- parse_release_date parses the release date formats accepted by the API,
+-- see releasedate.Parse. Values that are not dates, like month 13 or
+-- February 30, are dropped with a notice.
+CREATE FUNCTION pg_temp.parse_release_date(s TEXT) RETURNS DATE AS $$
+DECLARE
+    parts TEXT[];
+BEGIN
+    s := trim(s);
+    IF s = '' THEN
+        RETURN NULL;
+    END IF;
+
+    -- 2006-01-02, 2006/01/02 and RFC 3339 timestamps, whose date is taken
+    -- as written
+    parts := regexp_match(s, '^(\d{4})[-/](\d{1,2})[-/](\d{1,2})(T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2}))?$');
+    IF parts IS NOT NULL THEN
+        RETURN make_date(parts[1]::int, parts[2]::int, parts[3]::int);
+    END IF;
+
+    -- 02.01.2006 and 02/01/2006
+    parts := regexp_match(s, '^(\d{1,2})[./](\d{1,2})[./](\d{4})$');
+    IF parts IS NOT NULL THEN
+        RETURN make_date(parts[3]::int, parts[2]::int, parts[1]::int);
+    END IF;
+
+    -- 2 January 2006 and 2 Jan 2006
+    parts := regexp_match(s, '^(\d{1,2}) ([A-Za-z]+) (\d{4})$');
+    IF parts IS NOT NULL AND pg_temp.month_number(parts[2]) IS NOT NULL THEN
+        RETURN make_date(parts[3]::int, pg_temp.month_number(parts[2]), parts[1]::int);
+    END IF;
+
+    -- January 2, 2006 and Jan 2, 2006
+    parts := regexp_match(s, '^([A-Za-z]+) (\d{1,2}), (\d{4})$');
+    IF parts IS NOT NULL AND pg_temp.month_number(parts[1]) IS NOT NULL THEN
+        RETURN make_date(parts[3]::int, pg_temp.month_number(parts[1]), parts[2]::int);
+    END IF;
+
+    -- January 2006
+    parts := regexp_match(s, '^([A-Za-z]+) (\d{4})$');
+    IF parts IS NOT NULL AND pg_temp.month_number(parts[1]) IS NOT NULL THEN
+        RETURN make_date(parts[2]::int, pg_temp.month_number(parts[1]), 1);
+    END IF;
+
+    -- 2006-01
+    parts := regexp_match(s, '^(\d{4})-(\d{2})$');
+    IF parts IS NOT NULL THEN
+        RETURN make_date(parts[1]::int, parts[2]::int, 1);
+    END IF;
+
+    -- a bare year
+    IF s ~ '^\d{4}$' THEN
+        RETURN make_date(s::int, 1, 1);
+    END IF;
+
+    RAISE NOTICE 'dropping release date %: unknown format', quote_literal(s);
+    RETURN NULL;
+EXCEPTION
+    WHEN datetime_field_overflow OR invalid_datetime_format OR numeric_value_out_of_range THEN
+        RAISE NOTICE 'dropping release date %: %', quote_literal(s), SQLERRM;
+        RETURN NULL;
+END
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+DROP INDEX IF EXISTS songs_release_date_id_idx;
+ALTER TABLE songs ALTER COLUMN release_date DROP NOT NULL;
+ALTER TABLE songs ALTER COLUMN release_date TYPE DATE USING pg_temp.parse_release_date(release_date);
+CREATE INDEX IF NOT EXISTS songs_release_date_id_idx ON songs ((coalesce(release_date, '-infinity'::date)), id);
+
+UPDATE song_revisions
+SET release_date = coalesce(to_char(pg_temp.parse_release_date(release_date), 'YYYY-MM-DD'), '');
+COMMIT;
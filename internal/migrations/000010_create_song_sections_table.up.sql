@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS song_sections (
+    song_id UUID NOT NULL REFERENCES songs (uuid) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    label VARCHAR(255) NOT NULL,
+    "text" TEXT NOT NULL,
+    repeat_of INTEGER,
+    PRIMARY KEY (song_id, position)
+);
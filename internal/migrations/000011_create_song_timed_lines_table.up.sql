@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS song_timed_lines (
+    song_id UUID NOT NULL REFERENCES songs (uuid) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    start_ms INTEGER NOT NULL,
+    "text" TEXT NOT NULL,
+    PRIMARY KEY (song_id, position)
+);
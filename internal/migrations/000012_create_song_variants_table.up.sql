@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS song_variants (
+    song_id UUID NOT NULL REFERENCES songs (uuid) ON DELETE CASCADE,
+    lang VARCHAR(35) NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    "text" TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (song_id, lang, kind)
+);
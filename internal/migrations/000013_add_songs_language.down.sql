@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS songs_language_idx;
+
+ALTER TABLE songs DROP COLUMN IF EXISTS language_confidence;
+ALTER TABLE songs DROP COLUMN IF EXISTS language;
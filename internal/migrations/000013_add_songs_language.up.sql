@@ -0,0 +1,4 @@
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS language VARCHAR(35);
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS language_confidence REAL;
+
+CREATE INDEX IF NOT EXISTS songs_language_idx ON songs (language) WHERE deleted_at IS NULL;
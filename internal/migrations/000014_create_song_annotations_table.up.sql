@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS song_annotations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    song_id UUID NOT NULL REFERENCES songs (uuid) ON DELETE CASCADE,
+    line INTEGER NOT NULL,
+    start_offset INTEGER NOT NULL,
+    end_offset INTEGER NOT NULL,
+    quote TEXT NOT NULL,
+    body TEXT NOT NULL,
+    author VARCHAR(255) NOT NULL,
+    orphaned BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS song_annotations_song_id_idx ON song_annotations (song_id, line, start_offset);
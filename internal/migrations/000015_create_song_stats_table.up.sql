@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS song_stats (
+    song_id UUID PRIMARY KEY REFERENCES songs (uuid) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    stats JSONB NOT NULL,
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
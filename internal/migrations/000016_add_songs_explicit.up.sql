@@ -0,0 +1 @@
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS explicit BOOLEAN NOT NULL DEFAULT false;
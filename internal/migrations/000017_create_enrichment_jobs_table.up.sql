@@ -0,0 +1,16 @@
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS enrichment_status VARCHAR(16) NOT NULL DEFAULT 'done';
+
+CREATE TABLE IF NOT EXISTS enrichment_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    song_id UUID NOT NULL REFERENCES songs (uuid) ON DELETE CASCADE,
+    status VARCHAR(16) NOT NULL DEFAULT 'queued',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    run_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    locked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS enrichment_jobs_queued_idx ON enrichment_jobs (run_at) WHERE status = 'queued';
+CREATE INDEX IF NOT EXISTS enrichment_jobs_song_id_idx ON enrichment_jobs (song_id, created_at);
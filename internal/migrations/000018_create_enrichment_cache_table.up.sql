@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS enrichment_cache (
+    group_key TEXT NOT NULL,
+    song_key TEXT NOT NULL,
+    found BOOLEAN NOT NULL,
+    release_date TEXT NOT NULL DEFAULT '',
+    text TEXT NOT NULL DEFAULT '',
+    link TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (group_key, song_key)
+);
+
+CREATE INDEX IF NOT EXISTS enrichment_cache_expires_at_idx ON enrichment_cache (expires_at);
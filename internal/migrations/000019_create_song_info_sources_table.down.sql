@@ -0,0 +1,2 @@
+ALTER TABLE enrichment_cache DROP COLUMN IF EXISTS sources;
+DROP TABLE IF EXISTS song_info_sources;
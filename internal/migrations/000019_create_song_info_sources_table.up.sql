@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS song_info_sources (
+    song_id UUID NOT NULL REFERENCES songs (uuid) ON DELETE CASCADE,
+    field VARCHAR(32) NOT NULL,
+    provider VARCHAR(64) NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (song_id, field)
+);
+
+ALTER TABLE enrichment_cache ADD COLUMN IF NOT EXISTS sources JSONB NOT NULL DEFAULT '{}';
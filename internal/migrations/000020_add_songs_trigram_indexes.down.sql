@@ -0,0 +1,4 @@
+BEGIN;
+DROP INDEX IF EXISTS songs_song_trgm_idx;
+DROP INDEX IF EXISTS songs_group_trgm_idx;
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+CREATE INDEX IF NOT EXISTS songs_group_trgm_idx ON songs USING GIN (lower("group") gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS songs_song_trgm_idx ON songs USING GIN (lower(song) gin_trgm_ops);
+COMMIT;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS group_stats (
+    "group" VARCHAR(255) PRIMARY KEY,
+    songs_version TEXT NOT NULL,
+    songs INTEGER NOT NULL,
+    stats JSONB NOT NULL,
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,44 @@
+package purge
+
+import (
+	"context"
+	"time"
+
+	"github.com/foreground-eclipse/song-library/internal/logger"
+	"go.uber.org/zap"
+)
+
+type TrashPurger interface {
+	PurgeTrash(retention time.Duration) (int64, error)
+}
+
+// Run purges the songs kept in the trash for longer than the retention
+// every interval until the context is done
+func Run(ctx context.Context, log *logger.Logger, purger TrashPurger, retention, interval time.Duration) {
+	const op = "purge.Run"
+
+	if interval <= 0 {
+		log.LogInfo("trash purge is disabled", zap.String("op", op))
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := purger.PurgeTrash(retention)
+		if err != nil {
+			log.LogError("failed to purge the trash", zap.String("op", op),
+				zap.Error(err))
+		} else if n > 0 {
+			log.LogInfo("purged the trash", zap.String("op", op),
+				zap.Int64("songs", n))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
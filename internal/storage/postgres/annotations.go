@@ -0,0 +1,285 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/foreground-eclipse/song-library/internal/lib/anchor"
+)
+
+var ErrAnnotationNotFound = errors.New("annotation not found")
+
+// Annotation is an explanation attached to a range of a line of lyrics.
+// An orphaned annotation lost its text to an edit of the lyrics and keeps
+// its last anchor.
+type Annotation struct {
+	ID     string `json:"id"`
+	SongID string `json:"song_id"`
+	anchor.Anchor
+	Body      string    `json:"body"`
+	Author    string    `json:"author"`
+	Orphaned  bool      `json:"orphaned"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AnnotationPatch holds the annotation attributes to change, nil fields are
+// left as is. A new range is anchored on the current lyrics.
+type AnnotationPatch struct {
+	Body  *string
+	Range *Range
+}
+
+// Range is a range of characters on a line of lyrics, zero offsets cover the
+// whole line
+type Range struct {
+	Line  int
+	Start int
+	End   int
+}
+
+// Marker is an annotation embedded into lyrics
+type Marker struct {
+	ID string `json:"id"`
+	anchor.Anchor
+}
+
+const annotationColumns = `id, song_id, line, start_offset, end_offset, quote, body, author, orphaned, created_at, updated_at`
+
+func annotationFields(a *Annotation) []interface{} {
+	return []interface{}{&a.ID, &a.SongID, &a.Line, &a.Start, &a.End, &a.Quote, &a.Body, &a.Author,
+		&a.Orphaned, &a.CreatedAt, &a.UpdatedAt}
+}
+
+// AddAnnotation attaches the annotation to the range of the lyrics of the
+// song it refers to
+func (s *Storage) AddAnnotation(a Annotation, r Range) (Annotation, error) {
+	const op = "storage.postgres.AddAnnotation"
+
+	if !uuidPattern.MatchString(a.SongID) {
+		return Annotation{}, fmt.Errorf("%s: %w", op, ErrInvalidID)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Annotation{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	_, song, err := lockSong(tx, "uuid = $1", []interface{}{a.SongID}, 0)
+	if err != nil {
+		return Annotation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.Anchor, err = anchor.New(song.Text, r.Line, r.Start, r.End)
+	if err != nil {
+		return Annotation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var added Annotation
+	err = tx.QueryRow(`INSERT INTO song_annotations (song_id, line, start_offset, end_offset, quote, body, author)
+	VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING `+annotationColumns,
+		a.SongID, a.Line, a.Start, a.End, a.Quote, a.Body, a.Author).Scan(annotationFields(&added)...)
+	if err != nil {
+		return Annotation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Annotation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return added, nil
+}
+
+// ListAnnotations gets all the annotations of the song with given id in the
+// order of the lyrics
+func (s *Storage) ListAnnotations(songID string) ([]Annotation, error) {
+	const op = "storage.postgres.ListAnnotations"
+
+	if _, err := s.GetSongByID(songID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.db.Query("SELECT "+annotationColumns+` FROM song_annotations
+	WHERE song_id = $1 ORDER BY orphaned, line, start_offset, created_at`, songID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	annotations := make([]Annotation, 0)
+	for rows.Next() {
+		var a Annotation
+		if err := rows.Scan(annotationFields(&a)...); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		annotations = append(annotations, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return annotations, nil
+}
+
+// UpdateAnnotation changes the given attributes of the annotation with given
+// id of the song, anchoring a new range brings an orphaned annotation back
+func (s *Storage) UpdateAnnotation(songID, id string, patch AnnotationPatch) (Annotation, error) {
+	const op = "storage.postgres.UpdateAnnotation"
+
+	if !uuidPattern.MatchString(songID) || !uuidPattern.MatchString(id) {
+		return Annotation{}, fmt.Errorf("%s: %w", op, ErrInvalidID)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Annotation{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	_, song, err := lockSong(tx, "uuid = $1", []interface{}{songID}, 0)
+	if err != nil {
+		return Annotation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var a Annotation
+	err = tx.QueryRow("SELECT "+annotationColumns+" FROM song_annotations WHERE id = $1 AND song_id = $2 FOR UPDATE",
+		id, songID).Scan(annotationFields(&a)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Annotation{}, fmt.Errorf("%s: %w", op, ErrAnnotationNotFound)
+	}
+	if err != nil {
+		return Annotation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if patch.Body != nil {
+		a.Body = *patch.Body
+	}
+	if patch.Range != nil {
+		a.Anchor, err = anchor.New(song.Text, patch.Range.Line, patch.Range.Start, patch.Range.End)
+		if err != nil {
+			return Annotation{}, fmt.Errorf("%s: %w", op, err)
+		}
+		a.Orphaned = false
+	}
+
+	err = tx.QueryRow(`UPDATE song_annotations
+	SET line = $2, start_offset = $3, end_offset = $4, quote = $5, body = $6, orphaned = $7, updated_at = now()
+	WHERE id = $1 RETURNING `+annotationColumns,
+		id, a.Line, a.Start, a.End, a.Quote, a.Body, a.Orphaned).Scan(annotationFields(&a)...)
+	if err != nil {
+		return Annotation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Annotation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return a, nil
+}
+
+// DeleteAnnotation deletes the annotation with given id of the song
+func (s *Storage) DeleteAnnotation(songID, id string) error {
+	const op = "storage.postgres.DeleteAnnotation"
+
+	if !uuidPattern.MatchString(songID) || !uuidPattern.MatchString(id) {
+		return fmt.Errorf("%s: %w", op, ErrInvalidID)
+	}
+
+	res, err := s.db.Exec("DELETE FROM song_annotations WHERE id = $1 AND song_id = $2", id, songID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, ErrAnnotationNotFound)
+	}
+
+	return nil
+}
+
+// reanchorAnnotations moves the annotations of the song to the nearest
+// matching text of its edited lyrics, orphaning the ones whose text is gone
+// and anchoring the orphaned ones whose quote is back
+func reanchorAnnotations(tx *sql.Tx, songID, oldText, newText string) error {
+	if oldText == newText {
+		return nil
+	}
+
+	rows, err := tx.Query("SELECT "+annotationColumns+" FROM song_annotations WHERE song_id = $1 FOR UPDATE",
+		songID)
+	if err != nil {
+		return err
+	}
+
+	annotations := make([]Annotation, 0)
+	for rows.Next() {
+		var a Annotation
+		if err := rows.Scan(annotationFields(&a)...); err != nil {
+			rows.Close()
+			return err
+		}
+		annotations = append(annotations, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	m := anchor.Map(oldText, newText)
+	for _, a := range annotations {
+		if a.Orphaned {
+			recovered, ok := anchor.Recover(a.Anchor, m)
+			if !ok {
+				continue
+			}
+			_, err := tx.Exec(`UPDATE song_annotations
+			SET line = $2, start_offset = $3, end_offset = $4, orphaned = false
+			WHERE id = $1`, a.ID, recovered.Line, recovered.Start, recovered.End)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		moved, ok := anchor.Reanchor(a.Anchor, m)
+		if moved == a.Anchor && ok {
+			continue
+		}
+
+		_, err := tx.Exec(`UPDATE song_annotations
+		SET line = $2, start_offset = $3, end_offset = $4, quote = $5, orphaned = $6
+		WHERE id = $1`, a.ID, moved.Line, moved.Start, moved.End, moved.Quote, !ok)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// annotationMarkers gets the markers of the anchored annotations of the song
+func (s *Storage) annotationMarkers(songID string) ([]Marker, error) {
+	rows, err := s.db.Query(`SELECT id, line, start_offset, end_offset, quote FROM song_annotations
+	WHERE song_id = $1 AND NOT orphaned ORDER BY line, start_offset, created_at`, songID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	markers := make([]Marker, 0)
+	for rows.Next() {
+		var m Marker
+		if err := rows.Scan(&m.ID, &m.Line, &m.Start, &m.End, &m.Quote); err != nil {
+			return nil, err
+		}
+		markers = append(markers, m)
+	}
+
+	return markers, rows.Err()
+}
@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/foreground-eclipse/song-library/internal/lib/concordance"
+)
+
+// Occurrence is an occurrence of a phrase in the lyrics of a song
+type Occurrence struct {
+	SongID string `json:"song_id"`
+	Group  string `json:"group"`
+	Song   string `json:"song"`
+	concordance.Hit
+}
+
+// ConcordanceOptions describes paging and the context of a concordance
+type ConcordanceOptions struct {
+	Page     int
+	PageSize int
+	// Context is the number of words around an occurrence
+	Context int
+	// Clean leaves explicit songs out
+	Clean bool
+}
+
+// Concordance gets a page of occurrences of the phrase in the lyrics of
+// all the songs ordered by group, song and position, together with the total
+// number of occurrences. Songs are preselected with the full-text index and
+// their occurrences are counted in the database, so only the texts of the
+// songs on the page are read.
+func (s *Storage) Concordance(phrase string, opts ConcordanceOptions) ([]Occurrence, int, error) {
+	const op = "storage.postgres.Concordance"
+
+	words := concordance.Words(phrase)
+	if len(words) == 0 {
+		return nil, 0, fmt.Errorf("%s: %w", op, ErrEmptyQuery)
+	}
+	if opts.Page < 1 || opts.PageSize < 1 || opts.Context < 0 {
+		return nil, 0, fmt.Errorf("%s: %w", op, ErrInvalidPage)
+	}
+
+	where := "search_vector @@ phraseto_tsquery('simple', $1) AND deleted_at IS NULL"
+	if opts.Clean {
+		where += " AND NOT explicit"
+	}
+
+	// every song is numbered by the occurrences before it, the last song is
+	// always returned to tell the total
+	from := (opts.Page - 1) * opts.PageSize
+	to := from + opts.PageSize
+	rows, err := s.db.Query(`WITH counted AS (
+		SELECT id, uuid, "group", song, text,
+			(SELECT count(*) FROM regexp_matches(lower(text), $2, 'g')) AS n
+		FROM songs
+		WHERE `+where+`
+	), numbered AS (
+		SELECT *,
+			sum(n) OVER (ORDER BY "group", song, id) AS until,
+			sum(n) OVER () AS total
+		FROM counted
+		WHERE n > 0
+	)
+	SELECT uuid, "group", song,
+		CASE WHEN until > $3 AND until - n < $4 THEN text ELSE '' END,
+		(until - n)::bigint, n, total::bigint
+	FROM numbered
+	WHERE (until > $3 AND until - n < $4) OR until = total
+	ORDER BY "group", song, id`, strings.Join(words, " "), phrasePattern(words), from, to)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	occurrences := make([]Occurrence, 0, opts.PageSize)
+	total := 0
+	for rows.Next() {
+		var (
+			song     Song
+			first, n int
+		)
+		if err := rows.Scan(&song.ID, &song.Group, &song.Song, &song.Text, &first, &n, &total); err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		for i, hit := range concordance.Find(song.Text, words, opts.Context) {
+			if i >= n || first+i >= to {
+				break
+			}
+			if first+i >= from {
+				occurrences = append(occurrences, Occurrence{
+					SongID: song.ID,
+					Group:  song.Group,
+					Song:   song.Song,
+					Hit:    hit,
+				})
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return occurrences, total, nil
+}
+
+// phrasePattern returns the regular expression matching the start of every
+// occurrence of the lowercased phrase within a verse the way
+// concordance.Find does: the words are whole and separated by at most one
+// line break
+func phrasePattern(words []string) string {
+	const (
+		wordChar = `[[:alnum:]'’]`
+		sep      = `[^[:alnum:]'’\n]*\n?[^[:alnum:]'’\n]*`
+	)
+
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = regexp.QuoteMeta(strings.ToLower(w)) + `(?!` + wordChar + `)`
+	}
+	return `(?:^|[^[:alnum:]'’])(?=` + strings.Join(quoted, sep) + `)`
+}
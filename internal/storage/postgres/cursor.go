@@ -0,0 +1,175 @@
+package postgres
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorOptions describes a keyset page of a songs listing
+type CursorOptions struct {
+	Cursor   string
+	PageSize int
+	SortBy   string
+	Order    string
+	Released Released
+	// Clean leaves explicit songs out
+	Clean bool
+}
+
+// cursor is the last seen position of a keyset listing
+type cursor struct {
+	SortBy string `json:"s"`
+	Order  string `json:"o"`
+	// Filter is the hash of the conditions of the listing
+	Filter string `json:"f"`
+	Value  string `json:"v"`
+	ID     int64  `json:"id"`
+}
+
+func encodeCursor(c cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// filterHash returns the hash of the conditions selecting the songs of a
+// listing, a cursor is valid only for the listing it was made by
+func filterHash(where string, params []interface{}) string {
+	raw, _ := json.Marshal(append([]interface{}{where}, params...))
+	sum := sha256.Sum256(raw)
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	if _, ok := sortColumns[c.SortBy]; !ok {
+		return c, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// ListSongsByCursor gets the songs following the cursor position and the
+// cursor of the next page, which is empty when there are no more songs
+func (s *Storage) ListSongsByCursor(filter Song, opts CursorOptions) ([]Song, string, error) {
+	const op = "storage.postgres.ListSongsByCursor"
+
+	if opts.PageSize < 1 {
+		return nil, "", fmt.Errorf("%s: %w", op, ErrInvalidPage)
+	}
+
+	var after *cursor
+	if opts.Cursor != "" {
+		c, err := decodeCursor(opts.Cursor)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", op, err)
+		}
+		if (opts.SortBy != "" && opts.SortBy != c.SortBy) || (opts.Order != "" && !strings.EqualFold(opts.Order, c.Order)) {
+			return nil, "", fmt.Errorf("%s: %w: sort does not match the cursor", op, ErrInvalidCursor)
+		}
+		opts.SortBy, opts.Order = c.SortBy, c.Order
+		after = &c
+	}
+
+	column, ok := sortColumns[opts.SortBy]
+	if !ok {
+		return nil, "", fmt.Errorf("%s: %w: %q", op, ErrInvalidSort, opts.SortBy)
+	}
+	if opts.SortBy == "" {
+		opts.SortBy = "group"
+	}
+	order, cmp := "ASC", ">"
+	switch strings.ToLower(opts.Order) {
+	case "", "asc":
+		opts.Order = "asc"
+	case "desc":
+		order, cmp = "DESC", "<"
+		opts.Order = "desc"
+	default:
+		return nil, "", fmt.Errorf("%s: %w: %q", op, ErrInvalidOrder, opts.Order)
+	}
+
+	where, params := buildWhere(filter)
+	released, params := opts.Released.where(params)
+	where += released
+	if opts.Clean {
+		where += " AND NOT explicit"
+	}
+	hash := filterHash(where, params)
+	if after != nil {
+		if after.Filter != hash {
+			return nil, "", fmt.Errorf("%s: %w: filter does not match the cursor", op, ErrInvalidCursor)
+		}
+		where += fmt.Sprintf(" AND (%s, id) %s ($%d, $%d)", column, cmp, len(params)+1, len(params)+2)
+		params = append(params, after.Value, after.ID)
+	}
+
+	query := fmt.Sprintf(`SELECT id, %s FROM songs%s
+	ORDER BY %s %s, id %s LIMIT $%d`,
+		songColumns, where, column, order, order, len(params)+1)
+	params = append(params, opts.PageSize+1)
+
+	rows, err := s.db.Query(query, params...)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	songs := make([]Song, 0, opts.PageSize)
+	var lastID int64
+	hasMore := false
+	for rows.Next() {
+		if len(songs) == opts.PageSize {
+			hasMore = true
+			break
+		}
+		var song Song
+		err = rows.Scan(append([]interface{}{&lastID}, songFields(&song)...)...)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", op, err)
+		}
+		songs = append(songs, song)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !hasMore {
+		return songs, "", nil
+	}
+
+	last := songs[len(songs)-1]
+	next := cursor{
+		SortBy: opts.SortBy,
+		Order:  opts.Order,
+		Filter: hash,
+		ID:     lastID,
+	}
+	switch opts.SortBy {
+	case "group":
+		next.Value = last.Group
+	case "song":
+		next.Value = last.Song
+	case "release_date":
+		next.Value = last.ReleaseDate
+		if next.Value == "" {
+			// songs of unknown release date are sorted as the earliest ones
+			next.Value = "-infinity"
+		}
+	}
+
+	return songs, encodeCursor(next), nil
+}
@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []cursor{
+		{SortBy: "group", Order: "asc", Filter: "c2FtZSBmaWx0ZXI", Value: "Muse", ID: 1},
+		{SortBy: "song", Order: "desc", Value: "Звезда по имени Солнце", ID: 42},
+		{SortBy: "release_date", Order: "asc", Value: "-infinity", ID: 7},
+		{SortBy: "", Order: "asc", Value: `quotes " and \ slashes`, ID: 1 << 40},
+	}
+	for _, want := range tests {
+		t.Run(want.SortBy+"/"+want.Value, func(t *testing.T) {
+			got, err := decodeCursor(encodeCursor(want))
+			if err != nil {
+				t.Fatalf("decodeCursor() error = %v", err)
+			}
+			if got != want {
+				t.Errorf("decodeCursor() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "not a cursor!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"s":"group","v":"a","id":1}`))},
+		{"not json", encode("group:a:1")},
+		{"unknown sort", encode(`{"s":"text","o":"asc","v":"a","id":1}`)},
+		{"wrong types", encode(`{"s":"group","o":"asc","v":"a","id":"1"}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("decodeCursor() error = %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestFilterHash(t *testing.T) {
+	where, params := buildWhere(Song{Group: "Muse"})
+	hash := filterHash(where, params)
+
+	tests := []struct {
+		name   string
+		where  string
+		params []interface{}
+		same   bool
+	}{
+		{"same filter", where, []interface{}{"Muse"}, true},
+		{"other value", where, []interface{}{"Queen"}, false},
+		{"extra condition", where + " AND NOT explicit", params, false},
+		{"no filter", " WHERE deleted_at IS NULL", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filterHash(tt.where, tt.params); (got == hash) != tt.same {
+				t.Errorf("filterHash() = %q, hash of the listing %q, want same %t", got, hash, tt.same)
+			}
+		})
+	}
+}
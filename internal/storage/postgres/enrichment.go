@@ -0,0 +1,336 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrNoEnrichmentJobs = errors.New("no enrichment jobs are due")
+	ErrJobNotFound      = errors.New("enrichment job not found")
+)
+
+// Enrichment statuses of songs
+const (
+	EnrichmentPending = "pending"
+	EnrichmentDone    = "done"
+	EnrichmentFailed  = "failed"
+)
+
+// Statuses of enrichment jobs
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// EnrichmentJob is a queued lookup of the info of a song added without it.
+// A queued job runs once its run time comes, a running job is locked by a
+// worker.
+type EnrichmentJob struct {
+	ID        string    `json:"id"`
+	SongID    string    `json:"song_id"`
+	Status    string    `json:"status"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error,omitempty"`
+	RunAt     time.Time `json:"run_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Group and Song name the song the info is looked up for and Version is
+	// its version when the job was claimed
+	Group   string `json:"-"`
+	Song    string `json:"-"`
+	Version int    `json:"-"`
+}
+
+// EnrichmentStatus is the enrichment status of a song with the providers of
+// its attributes and its latest job, songs enriched when added have no job
+type EnrichmentStatus struct {
+	SongID  string         `json:"song_id"`
+	Status  string         `json:"status"`
+	Sources Sources        `json:"sources"`
+	Job     *EnrichmentJob `json:"job,omitempty"`
+}
+
+const jobColumns = `j.id, j.song_id, j.status, j.attempts, j.last_error, j.run_at, j.created_at, j.updated_at`
+
+func jobFields(j *EnrichmentJob) []interface{} {
+	return []interface{}{&j.ID, &j.SongID, &j.Status, &j.Attempts, &j.LastError, &j.RunAt, &j.CreatedAt, &j.UpdatedAt}
+}
+
+// AddPendingSong adds the song on behalf of the author with a pending
+// enrichment status and queues the lookup of its info
+func (s *Storage) AddPendingSong(song Song, author string) (Song, EnrichmentJob, error) {
+	const op = "storage.postgres.AddPendingSong"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Song{}, EnrichmentJob{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	song.EnrichmentStatus = EnrichmentPending
+	added, err := s.addSong(tx, song, author)
+	if err != nil {
+		return Song{}, EnrichmentJob{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var job EnrichmentJob
+	err = tx.QueryRow("INSERT INTO enrichment_jobs AS j (song_id) VALUES ($1) RETURNING "+jobColumns,
+		added.ID).Scan(jobFields(&job)...)
+	if err != nil {
+		return Song{}, EnrichmentJob{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Song{}, EnrichmentJob{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return added, job, nil
+}
+
+// ClaimEnrichmentJob locks the earliest due job for the caller and counts the
+// attempt, jobs locked by other workers are skipped
+func (s *Storage) ClaimEnrichmentJob() (EnrichmentJob, error) {
+	const op = "storage.postgres.ClaimEnrichmentJob"
+
+	var job EnrichmentJob
+	err := s.db.QueryRow(`UPDATE enrichment_jobs j
+	SET status = $1, attempts = j.attempts + 1, locked_at = now(), updated_at = now()
+	FROM songs s
+	WHERE j.id = (
+		SELECT id FROM enrichment_jobs
+		WHERE status = $2 AND run_at <= now()
+		ORDER BY run_at, created_at
+		LIMIT 1 FOR UPDATE SKIP LOCKED
+	) AND s.uuid = j.song_id
+	RETURNING `+jobColumns+`, s."group", s.song, s.version`, JobRunning, JobQueued).
+		Scan(append(jobFields(&job), &job.Group, &job.Song, &job.Version)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return EnrichmentJob{}, ErrNoEnrichmentJobs
+	}
+	if err != nil {
+		return EnrichmentJob{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return job, nil
+}
+
+// CompleteEnrichmentJob applies the info found by the running job with given
+// id to its song and marks both enriched. When the song has changed since the
+// version of the change, only its attributes that are still empty are set so
+// that the edits made meanwhile are kept.
+func (s *Storage) CompleteEnrichmentJob(id string, patch SongPatch, change Change) (Song, error) {
+	const op = "storage.postgres.CompleteEnrichmentJob"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	songID, err := lockJob(tx, id)
+	if err != nil {
+		return Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, current, err := lockSong(tx, "uuid = $1", []interface{}{songID}, 0)
+	if err != nil {
+		return Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if change.Version != 0 && change.Version != current.Version {
+		patch = emptyOnly(patch, current)
+	}
+	change.Version = current.Version
+
+	song, err := s.patchSongTx(tx, "uuid = $1", []interface{}{songID}, patch, change)
+	if err != nil {
+		return Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.Exec("UPDATE songs SET enrichment_status = $2 WHERE uuid = $1", songID, EnrichmentDone); err != nil {
+		return Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+	song.EnrichmentStatus = EnrichmentDone
+
+	_, err = tx.Exec(`UPDATE enrichment_jobs SET status = $2, last_error = '', locked_at = NULL, updated_at = now()
+	WHERE id = $1`, id, JobDone)
+	if err != nil {
+		return Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return song, nil
+}
+
+// RetryEnrichmentJob puts the running job with given id back in the queue to
+// run after the delay
+func (s *Storage) RetryEnrichmentJob(id, cause string, delay time.Duration) error {
+	const op = "storage.postgres.RetryEnrichmentJob"
+
+	res, err := s.db.Exec(`UPDATE enrichment_jobs
+	SET status = $2, last_error = $3, run_at = now() + $4 * interval '1 millisecond', locked_at = NULL, updated_at = now()
+	WHERE id = $1 AND status = $5`, id, JobQueued, cause, delay.Milliseconds(), JobRunning)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, ErrJobNotFound)
+	}
+
+	return nil
+}
+
+// ReleaseEnrichmentJob puts the running job with given id back in the queue
+// to run at once, the attempt it was claimed for does not count
+func (s *Storage) ReleaseEnrichmentJob(id string) error {
+	const op = "storage.postgres.ReleaseEnrichmentJob"
+
+	res, err := s.db.Exec(`UPDATE enrichment_jobs
+	SET status = $2, attempts = greatest(attempts - 1, 0), run_at = now(), locked_at = NULL, updated_at = now()
+	WHERE id = $1 AND status = $3`, id, JobQueued, JobRunning)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, ErrJobNotFound)
+	}
+
+	return nil
+}
+
+// FailEnrichmentJob gives up the running job with given id and marks its
+// song as failed to enrich
+func (s *Storage) FailEnrichmentJob(id, cause string) error {
+	const op = "storage.postgres.FailEnrichmentJob"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	songID, err := lockJob(tx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(`UPDATE enrichment_jobs SET status = $2, last_error = $3, locked_at = NULL, updated_at = now()
+	WHERE id = $1`, id, JobFailed, cause)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.Exec("UPDATE songs SET enrichment_status = $2 WHERE uuid = $1", songID, EnrichmentFailed); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RequeueStaleEnrichmentJobs puts the jobs running for longer than the
+// timeout, left by stopped workers, back in the queue and returns their
+// number
+func (s *Storage) RequeueStaleEnrichmentJobs(timeout time.Duration) (int64, error) {
+	const op = "storage.postgres.RequeueStaleEnrichmentJobs"
+
+	res, err := s.db.Exec(`UPDATE enrichment_jobs
+	SET status = $1, run_at = now(), locked_at = NULL, updated_at = now()
+	WHERE status = $2 AND locked_at < now() - $3 * interval '1 millisecond'`,
+		JobQueued, JobRunning, timeout.Milliseconds())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// GetEnrichmentStatus gets the enrichment status of the song with given id
+// and its latest job
+func (s *Storage) GetEnrichmentStatus(songID string) (EnrichmentStatus, error) {
+	const op = "storage.postgres.GetEnrichmentStatus"
+
+	song, err := s.GetSongByID(songID)
+	if err != nil {
+		return EnrichmentStatus{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	status := EnrichmentStatus{SongID: song.ID, Status: song.EnrichmentStatus}
+	status.Sources, err = s.getSources(song.ID)
+	if err != nil {
+		return EnrichmentStatus{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var job EnrichmentJob
+	err = s.db.QueryRow("SELECT "+jobColumns+` FROM enrichment_jobs j
+	WHERE j.song_id = $1 ORDER BY j.created_at DESC LIMIT 1`, songID).Scan(jobFields(&job)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return status, nil
+	}
+	if err != nil {
+		return EnrichmentStatus{}, fmt.Errorf("%s: %w", op, err)
+	}
+	job.Group, job.Song = song.Group, song.Song
+	status.Job = &job
+
+	return status, nil
+}
+
+// lockJob locks the running job with given id and returns the id of its song
+func lockJob(tx *sql.Tx, id string) (string, error) {
+	var songID string
+	err := tx.QueryRow("SELECT song_id FROM enrichment_jobs WHERE id = $1 AND status = $2 FOR UPDATE",
+		id, JobRunning).Scan(&songID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrJobNotFound
+	}
+	return songID, err
+}
+
+// emptyOnly returns the part of the patch setting the attributes that are
+// empty in the song
+func emptyOnly(patch SongPatch, song Song) SongPatch {
+	kept := SongPatch{Sources: make(Sources)}
+	for _, f := range []struct {
+		field   string
+		current string
+		value   *string
+		set     func(*string)
+	}{
+		{"release_date", song.ReleaseDate, patch.ReleaseDate, func(v *string) { kept.ReleaseDate = v }},
+		{"text", song.Text, patch.Text, func(v *string) { kept.Text = v }},
+		{"link", song.Link, patch.Link, func(v *string) { kept.Link = v }},
+	} {
+		if f.value == nil || f.current != "" {
+			continue
+		}
+		f.set(f.value)
+		if source, ok := patch.Sources[f.field]; ok {
+			kept.Sources[f.field] = source
+		}
+	}
+	return kept
+}
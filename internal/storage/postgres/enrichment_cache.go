@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrCacheMiss = errors.New("song info is not cached")
+
+// CachedInfo is a cached response of the music info API, a response for a
+// song unknown to the API is not found
+type CachedInfo struct {
+	Found       bool
+	ReleaseDate string
+	Text        string
+	Link        string
+	Sources     Sources
+	ExpiresAt   time.Time
+}
+
+// GetCachedInfo gets the unexpired cached info of the song with given keys
+func (s *Storage) GetCachedInfo(group, song string) (CachedInfo, error) {
+	const op = "storage.postgres.GetCachedInfo"
+
+	var info CachedInfo
+	var sources []byte
+	err := s.db.QueryRow(`SELECT found, release_date, text, link, sources, expires_at FROM enrichment_cache
+	WHERE group_key = $1 AND song_key = $2 AND expires_at > now()`, group, song).
+		Scan(&info.Found, &info.ReleaseDate, &info.Text, &info.Link, &sources, &info.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return CachedInfo{}, ErrCacheMiss
+	}
+	if err != nil {
+		return CachedInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := json.Unmarshal(sources, &info.Sources); err != nil {
+		return CachedInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return info, nil
+}
+
+// SaveCachedInfo caches the info of the song with given keys, replacing the
+// info cached before
+func (s *Storage) SaveCachedInfo(group, song string, info CachedInfo) error {
+	const op = "storage.postgres.SaveCachedInfo"
+
+	sources, err := json.Marshal(info.Sources)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = s.db.Exec(`INSERT INTO enrichment_cache (group_key, song_key, found, release_date, text, link, sources, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	ON CONFLICT (group_key, song_key) DO UPDATE
+	SET found = excluded.found, release_date = excluded.release_date, text = excluded.text,
+		link = excluded.link, sources = excluded.sources, expires_at = excluded.expires_at`,
+		group, song, info.Found, info.ReleaseDate, info.Text, info.Link, sources, info.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// PurgeCachedInfo deletes the expired cached info and returns the number of
+// deleted entries
+func (s *Storage) PurgeCachedInfo() (int64, error) {
+	const op = "storage.postgres.PurgeCachedInfo"
+
+	res, err := s.db.Exec("DELETE FROM enrichment_cache WHERE expires_at <= now()")
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
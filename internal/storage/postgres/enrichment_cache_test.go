@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newTestStorage returns a storage of the database given by TEST_DATABASE_URL
+// with an empty temporary enrichment cache, the test is skipped without it
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	// the temporary table lives in the session of the only connection
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`CREATE TEMP TABLE enrichment_cache (
+		group_key TEXT NOT NULL,
+		song_key TEXT NOT NULL,
+		found BOOLEAN NOT NULL,
+		release_date TEXT NOT NULL DEFAULT '',
+		text TEXT NOT NULL DEFAULT '',
+		link TEXT NOT NULL DEFAULT '',
+		sources JSONB NOT NULL DEFAULT '{}',
+		expires_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (group_key, song_key)
+	)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Storage{db: db}
+}
+
+func TestCachedInfo(t *testing.T) {
+	s := newTestStorage(t)
+	now := time.Now().Truncate(time.Microsecond)
+
+	found := CachedInfo{
+		Found:       true,
+		ReleaseDate: "16.07.2006",
+		Text:        "lyrics",
+		Link:        "http://example.com",
+		Sources:     Sources{"text": "api"},
+		ExpiresAt:   now.Add(time.Hour),
+	}
+	unknown := CachedInfo{Sources: Sources{}, ExpiresAt: now.Add(time.Hour)}
+	expired := CachedInfo{Found: true, Text: "old", Sources: Sources{}, ExpiresAt: now.Add(-time.Hour)}
+
+	tests := []struct {
+		name  string
+		song  string
+		saved []CachedInfo
+		want  CachedInfo
+		err   error
+	}{
+		{name: "not cached", song: "a", err: ErrCacheMiss},
+		{name: "found", song: "b", saved: []CachedInfo{found}, want: found},
+		{name: "unknown", song: "c", saved: []CachedInfo{unknown}, want: unknown},
+		{name: "expired", song: "d", saved: []CachedInfo{expired}, err: ErrCacheMiss},
+		{name: "replaced", song: "e", saved: []CachedInfo{expired, found}, want: found},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, info := range tt.saved {
+				if err := s.SaveCachedInfo("muse", tt.song, info); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := s.GetCachedInfo("muse", tt.song)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("GetCachedInfo() error = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if !got.ExpiresAt.Equal(tt.want.ExpiresAt) {
+				t.Errorf("GetCachedInfo() expires at %v, want %v", got.ExpiresAt, tt.want.ExpiresAt)
+			}
+			got.ExpiresAt = tt.want.ExpiresAt
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetCachedInfo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	n, err := s.PurgeCachedInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("PurgeCachedInfo() = %d, want 1", n)
+	}
+}
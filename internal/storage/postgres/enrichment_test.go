@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEmptyOnly(t *testing.T) {
+	date, text, link := "2006-07-16", "lyrics", "http://api"
+	patch := SongPatch{
+		ReleaseDate: &date,
+		Text:        &text,
+		Link:        &link,
+		Sources:     Sources{"release_date": "file", "text": "api", "link": "api"},
+	}
+
+	tests := []struct {
+		name string
+		song Song
+		want SongPatch
+	}{
+		{
+			name: "nothing edited",
+			want: patch,
+		},
+		{
+			name: "text edited",
+			song: Song{Text: "curated lyrics"},
+			want: SongPatch{
+				ReleaseDate: &date,
+				Link:        &link,
+				Sources:     Sources{"release_date": "file", "link": "api"},
+			},
+		},
+		{
+			name: "everything edited",
+			song: Song{ReleaseDate: "2006-01-01", Text: "curated lyrics", Link: "http://curated"},
+			want: SongPatch{Sources: Sources{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := emptyOnly(patch, tt.song); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("emptyOnly() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
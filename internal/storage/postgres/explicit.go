@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// tagExplicit records whether the lyrics of the song are explicit
+func (s *Storage) tagExplicit(tx *sql.Tx, song *Song) error {
+	explicit := s.explicit.Explicit(song.Text)
+
+	if _, err := tx.Exec("UPDATE songs SET explicit = $2 WHERE uuid = $1", song.ID, explicit); err != nil {
+		return err
+	}
+
+	song.Explicit = explicit
+	return nil
+}
+
+// MaskExplicit masks the offending words of the lyrics
+func (s *Storage) MaskExplicit(text string) string {
+	return s.explicit.Mask(text)
+}
+
+// BackfillExplicit classifies the lyrics of a batch of songs, trashed ones
+// included, following the song with given row id. It returns the row id of
+// the last song of the batch to continue from and the number of classified
+// songs, which is zero when there are no more songs.
+func (s *Storage) BackfillExplicit(after, batch int) (int, int, error) {
+	const op = "storage.postgres.BackfillExplicit"
+
+	if batch < 1 {
+		return after, 0, fmt.Errorf("%s: %w", op, ErrInvalidPage)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return after, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id, uuid, text FROM songs WHERE id > $1 ORDER BY id LIMIT $2 FOR UPDATE",
+		after, batch)
+	if err != nil {
+		return after, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	songs := make([]Song, 0, batch)
+	last := after
+	for rows.Next() {
+		var song Song
+		if err := rows.Scan(&last, &song.ID, &song.Text); err != nil {
+			rows.Close()
+			return after, 0, fmt.Errorf("%s: %w", op, err)
+		}
+		songs = append(songs, song)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return after, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range songs {
+		if err := s.tagExplicit(tx, &songs[i]); err != nil {
+			return after, 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return after, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return last, len(songs), nil
+}
@@ -0,0 +1,176 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Match is a lookup mode of group and song names
+type Match string
+
+const (
+	MatchExact Match = "exact"
+	MatchFuzzy Match = "fuzzy"
+
+	// DefaultSimilarityThreshold is the minimal trigram similarity of a fuzzy match
+	DefaultSimilarityThreshold = 0.3
+)
+
+var (
+	ErrInvalidMatch     = errors.New("invalid match mode")
+	ErrInvalidThreshold = errors.New("similarity threshold must be between 0 and 1")
+)
+
+// ParseMatch parses the match mode accepted from clients
+func ParseMatch(s string) (Match, error) {
+	switch Match(strings.ToLower(s)) {
+	case "", MatchExact:
+		return MatchExact, nil
+	case MatchFuzzy:
+		return MatchFuzzy, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidMatch, s)
+	}
+}
+
+// FuzzyOptions describes paging and similarity threshold of a fuzzy lookup
+type FuzzyOptions struct {
+	Page      int
+	PageSize  int
+	Threshold float64
+	Released  Released
+	// Clean leaves explicit songs out
+	Clean bool
+}
+
+// ScoredSong is a song found by fuzzy lookup with its similarity to the filter
+type ScoredSong struct {
+	Song
+	Similarity float64 `json:"similarity"`
+}
+
+// FuzzySongs gets a page of songs whose group and song names are similar to
+// the filter ignoring case and typos, most similar first, together with the
+// total number of matches. Other fields of the filter must match exactly.
+func (s *Storage) FuzzySongs(filter Song, opts FuzzyOptions) ([]ScoredSong, int, error) {
+	const op = "storage.postgres.FuzzySongs"
+
+	if opts.Page < 1 || opts.PageSize < 1 {
+		return nil, 0, fmt.Errorf("%s: %w", op, ErrInvalidPage)
+	}
+	if opts.Threshold == 0 {
+		opts.Threshold = DefaultSimilarityThreshold
+	}
+	if opts.Threshold < 0 || opts.Threshold > 1 {
+		return nil, 0, fmt.Errorf("%s: %w", op, ErrInvalidThreshold)
+	}
+
+	where, score, params := buildFuzzyWhere(filter)
+	released, params := opts.Released.where(params)
+	where += released
+	if opts.Clean {
+		where += " AND NOT explicit"
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	// the % operator filters by the threshold of the transaction so that the
+	// trigram indexes are used
+	_, err = tx.Exec("SELECT set_config('pg_trgm.similarity_threshold', $1, true)",
+		strconv.FormatFloat(opts.Threshold, 'g', -1, 64))
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var total int
+	err = tx.QueryRow("SELECT count(*) FROM songs"+where, params...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := fmt.Sprintf(`SELECT %s, %s AS similarity
+	FROM songs%s
+	ORDER BY similarity DESC, id LIMIT $%d OFFSET $%d`,
+		songColumns, score, where, len(params)+1, len(params)+2)
+	params = append(params, opts.PageSize, (opts.Page-1)*opts.PageSize)
+
+	rows, err := tx.Query(query, params...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	songs := make([]ScoredSong, 0, opts.PageSize)
+	for rows.Next() {
+		var song ScoredSong
+		err = rows.Scan(append(songFields(&song.Song), &song.Similarity)...)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", op, err)
+		}
+		songs = append(songs, song)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	rows.Close()
+
+	if err := tx.Commit(); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return songs, total, nil
+}
+
+// FindSong gets the song most similar to the filter
+func (s *Storage) FindSong(filter Song, threshold float64) (ScoredSong, error) {
+	const op = "storage.postgres.FindSong"
+
+	songs, _, err := s.FuzzySongs(filter, FuzzyOptions{Page: 1, PageSize: 1, Threshold: threshold})
+	if err != nil {
+		return ScoredSong{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(songs) == 0 {
+		return ScoredSong{}, fmt.Errorf("%s: %w", op, ErrNotFound)
+	}
+
+	return songs[0], nil
+}
+
+// buildFuzzyWhere builds the WHERE clause matching the group and song of the
+// filter by trigram similarity above pg_trgm.similarity_threshold and the
+// rest of the fields exactly, and the expression of the resulting similarity
+// score
+func buildFuzzyWhere(filter Song) (string, string, []interface{}) {
+	fuzzy := filter
+	fuzzy.Group, fuzzy.Song = "", ""
+	where, params := buildWhere(fuzzy)
+
+	conditions := make([]string, 0, 2)
+	scores := make([]string, 0, 2)
+	for _, f := range []struct{ column, value string }{
+		{`"group"`, filter.Group},
+		{"song", filter.Song},
+	} {
+		if f.value == "" {
+			continue
+		}
+		params = append(params, strings.ToLower(f.value))
+		scores = append(scores, fmt.Sprintf("similarity(lower(%s), $%d)", f.column, len(params)))
+		conditions = append(conditions, fmt.Sprintf("lower(%s) %% $%d", f.column, len(params)))
+	}
+
+	if len(scores) == 0 {
+		return where, "1.0", params
+	}
+
+	where += " AND " + strings.Join(conditions, " AND ")
+	score := fmt.Sprintf("(%s) / %d", strings.Join(scores, " + "), len(scores))
+
+	return where, score, params
+}
@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/foreground-eclipse/song-library/internal/lib/langdetect"
+)
+
+// tagLanguage records the detected language of the lyrics of the song
+func tagLanguage(tx *sql.Tx, song *Song) error {
+	lang, confidence := langdetect.Detect(song.Text)
+
+	_, err := tx.Exec("UPDATE songs SET language = $2, language_confidence = $3 WHERE uuid = $1",
+		song.ID, lang, confidence)
+	if err != nil {
+		return err
+	}
+
+	song.Language = lang
+	song.LanguageConfidence = confidence
+	return nil
+}
+
+// BackfillLanguages detects the language of the lyrics of a batch of songs,
+// trashed ones included, following the song with given row id. Only songs
+// without a language are tagged unless redetect is set. It returns the row id
+// of the last song of the batch to continue from and the number of tagged
+// songs, which is zero when there are no more songs to tag.
+func (s *Storage) BackfillLanguages(after, batch int, redetect bool) (int, int, error) {
+	const op = "storage.postgres.BackfillLanguages"
+
+	if batch < 1 {
+		return after, 0, fmt.Errorf("%s: %w", op, ErrInvalidPage)
+	}
+
+	where := "id > $1"
+	if !redetect {
+		where += " AND language IS NULL"
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return after, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id, uuid, text FROM songs WHERE "+where+" ORDER BY id LIMIT $2 FOR UPDATE",
+		after, batch)
+	if err != nil {
+		return after, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	songs := make([]Song, 0, batch)
+	last := after
+	for rows.Next() {
+		var song Song
+		if err := rows.Scan(&last, &song.ID, &song.Text); err != nil {
+			rows.Close()
+			return after, 0, fmt.Errorf("%s: %w", op, err)
+		}
+		songs = append(songs, song)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return after, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range songs {
+		if err := tagLanguage(tx, &songs[i]); err != nil {
+			return after, 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return after, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return last, len(songs), nil
+}
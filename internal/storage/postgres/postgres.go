@@ -2,24 +2,107 @@ package postgres
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"reflect"
+	"regexp"
 	"strings"
 
 	"github.com/foreground-eclipse/song-library/internal/config"
+	"github.com/foreground-eclipse/song-library/internal/lib/explicit"
+	"github.com/foreground-eclipse/song-library/internal/lib/lyrics"
 	_ "github.com/lib/pq"
 )
 
 type Storage struct {
-	db *sql.DB
+	db       *sql.DB
+	explicit *explicit.Classifier
+}
+
+var (
+	ErrInvalidSort  = errors.New("invalid sort field")
+	ErrInvalidOrder = errors.New("invalid sort order")
+	ErrInvalidPage  = errors.New("invalid page")
+
+	ErrPageOutOfRange = errors.New("page is out of range")
+	ErrInvalidID      = errors.New("invalid song id")
+	ErrNotFound       = errors.New("song not found")
+
+	ErrVersionConflict = errors.New("song version does not match")
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// sortColumns maps the sort fields accepted from clients to table columns
+var sortColumns = map[string]string{
+	"":             `"group"`,
+	"group":        `"group"`,
+	"song":         "song",
+	"release_date": "coalesce(release_date, '-infinity'::date)",
 }
 
 type Song struct {
+	ID          string `json:"id" db:"uuid"`
 	Group       string `json:"group" db:"group"`
 	Song        string `json:"song" db:"song"`
 	ReleaseDate string `json:"release_date" db:"release_date"`
 	Text        string `json:"text" db:"text"`
 	Link        string `json:"link" db:"link"`
+	Version     int    `json:"version" db:"version"`
+
+	Language           string  `json:"language" db:"language"`
+	LanguageConfidence float64 `json:"language_confidence" db:"language_confidence"`
+
+	Explicit bool `json:"explicit" db:"explicit"`
+
+	EnrichmentStatus string `json:"enrichment_status" db:"enrichment_status"`
+	// Sources are the providers of the attributes found by enrichment when
+	// the song is added
+	Sources Sources `json:"sources,omitempty" db:"-"`
+}
+
+// songColumns are the selected columns of a song in the order of songFields,
+// release dates are selected in the ISO format and empty when unknown, the
+// language is empty until detected
+const songColumns = `uuid, "group", song, coalesce(to_char(release_date, 'YYYY-MM-DD'), ''), text, link, version,
+	coalesce(language, ''), coalesce(language_confidence, 0), explicit, enrichment_status`
+
+// songFields returns the scan destinations of a song for songColumns
+func songFields(song *Song) []interface{} {
+	return []interface{}{&song.ID, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link, &song.Version,
+		&song.Language, &song.LanguageConfidence, &song.Explicit, &song.EnrichmentStatus}
+}
+
+// ListOptions describes paging and ordering of a songs listing
+type ListOptions struct {
+	Page     int
+	PageSize int
+	SortBy   string
+	Order    string
+	Released Released
+	// Clean leaves explicit songs out
+	Clean bool
+}
+
+// Released is a half-open range of release dates in the ISO format, empty
+// bounds are open
+type Released struct {
+	From  string
+	Until string
+}
+
+// where returns the conditions selecting the songs released within the range
+func (r Released) where(params []interface{}) (string, []interface{}) {
+	var where string
+	if r.From != "" {
+		params = append(params, r.From)
+		where += fmt.Sprintf(" AND release_date >= $%d", len(params))
+	}
+	if r.Until != "" {
+		params = append(params, r.Until)
+		where += fmt.Sprintf(" AND release_date < $%d", len(params))
+	}
+	return where, params
 }
 
 // New initializing new database connection
@@ -42,51 +125,84 @@ func New(cfg *config.Config) (*Storage, error) {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	classifier := explicit.Default()
+	if cfg.Explicit.WordsFile != "" {
+		classifier, err = explicit.Load(cfg.Explicit.WordsFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
 	return &Storage{
-		db: db,
+		db:       db,
+		explicit: classifier,
 	}, nil
 }
 
-func (s *Storage) AddSong(song Song) error {
+// AddSong adds the song on behalf of the author and returns it as stored
+func (s *Storage) AddSong(song Song, author string) (Song, error) {
 	const op = "storage.postgres.AddSong"
 
-	_, err := s.db.Exec(`insert into songs ("group", song, release_date, text, link)
-	values ($1, $2, $3, $4, $5);`,
-		song.Group, song.Song, song.ReleaseDate, song.Text, song.Link)
-	fmt.Println(song.Group)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
 
+	song.EnrichmentStatus = EnrichmentDone
+	added, err := s.addSong(tx, song, author)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return Song{}, fmt.Errorf("%s: %w", op, err)
 	}
-	return nil
+
+	if err := tx.Commit(); err != nil {
+		return Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return added, nil
 }
 
-// GetSongs gets all the songs from database with given filter and page
-func (s *Storage) GetSongs(filter Song, page int) (Song, error) {
-	const op = "storage.postgres.GetSongs"
+// addSong inserts the song with its revision, sections and tags
+func (s *Storage) addSong(tx *sql.Tx, song Song, author string) (Song, error) {
+	var added Song
+	err := tx.QueryRow(`insert into songs ("group", song, release_date, text, link, enrichment_status)
+	values ($1, $2, NULLIF($3, '')::date, $4, $5, $6) returning `+songColumns+`;`,
+		song.Group, song.Song, song.ReleaseDate, song.Text, song.Link, song.EnrichmentStatus).Scan(songFields(&added)...)
+	if err != nil {
+		return Song{}, err
+	}
 
-	// Build the base query
-	query := "SELECT \"group\", song, release_date, text, link FROM songs WHERE "
-	params := make([]interface{}, 0)
+	if err := recordRevision(tx, added, OperationCreate, author); err != nil {
+		return Song{}, err
+	}
 
-	rv := reflect.ValueOf(filter)
-	rt := rv.Type()
+	if _, err := saveSections(tx, added.ID, added.Text); err != nil {
+		return Song{}, err
+	}
 
-	for i := 0; i < rt.NumField(); i++ {
-		field := rt.Field(i)
-		value := rv.Field(i).Interface()
+	if err := tagLanguage(tx, &added); err != nil {
+		return Song{}, err
+	}
 
-		if value != "" {
-			fmt.Println(field.Name)
-			query += fmt.Sprintf("\"%s\" = $%d AND ", strings.ToLower(field.Tag.Get("db")), len(params)+1)
-			params = append(params, value)
-		}
+	if err := s.tagExplicit(tx, &added); err != nil {
+		return Song{}, err
 	}
 
-	// Удалить последний " AND "
-	if len(params) > 0 {
-		query = query[:len(query)-5]
+	if err := recordSources(tx, added.ID, Song{}, added, song.Sources); err != nil {
+		return Song{}, err
 	}
+	added.Sources = song.Sources
+
+	return added, nil
+}
+
+// GetSongs gets all the songs from database with given filter and page
+func (s *Storage) GetSongs(filter Song, page int) (Song, error) {
+	const op = "storage.postgres.GetSongs"
+
+	// Build the base query
+	query := "SELECT " + songColumns + " FROM songs"
+	where, params := buildWhere(filter)
+	query += where
 
 	// Добавить пагинацию
 	query += fmt.Sprintf(" LIMIT 1 OFFSET %d", page-1)
@@ -101,7 +217,7 @@ func (s *Storage) GetSongs(filter Song, page int) (Song, error) {
 
 	// Scan the results and return them
 	for rows.Next() {
-		err = rows.Scan(&song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link)
+		err = rows.Scan(songFields(&song)...)
 		if err != nil {
 			return song, fmt.Errorf("%s: %w", op, err)
 		}
@@ -110,84 +226,416 @@ func (s *Storage) GetSongs(filter Song, page int) (Song, error) {
 	return song, nil
 }
 
-// GetCouplet gets all couplets from the song with given filter
-func (s *Storage) GetCouplet(filter Song, page int) (string, error) {
-	const op = "storage.postgres.GetSongs"
+// ListSongs gets a page of songs matching the filter together with
+// the total number of matching songs
+func (s *Storage) ListSongs(filter Song, opts ListOptions) ([]Song, int, error) {
+	const op = "storage.postgres.ListSongs"
 
-	query := "SELECT  text FROM songs WHERE "
-	params := make([]interface{}, 0)
-
-	rv := reflect.ValueOf(filter)
-	rt := rv.Type()
-
-	for i := 0; i < rt.NumField(); i++ {
-		field := rt.Field(i)
-		value := rv.Field(i).Interface()
+	column, ok := sortColumns[opts.SortBy]
+	if !ok {
+		return nil, 0, fmt.Errorf("%s: %w: %q", op, ErrInvalidSort, opts.SortBy)
+	}
+	order := "ASC"
+	switch strings.ToLower(opts.Order) {
+	case "", "asc":
+	case "desc":
+		order = "DESC"
+	default:
+		return nil, 0, fmt.Errorf("%s: %w: %q", op, ErrInvalidOrder, opts.Order)
+	}
+	if opts.Page < 1 || opts.PageSize < 1 {
+		return nil, 0, fmt.Errorf("%s: %w", op, ErrInvalidPage)
+	}
 
-		if value != "" {
-			fmt.Println(field.Name)
-			query += fmt.Sprintf("\"%s\" = $%d AND ", strings.ToLower(field.Tag.Get("db")), len(params)+1)
-			params = append(params, value)
-		}
+	where, params := buildWhere(filter)
+	released, params := opts.Released.where(params)
+	where += released
+	if opts.Clean {
+		where += " AND NOT explicit"
 	}
 
-	// Удалить последний " AND "
-	if len(params) > 0 {
-		query = query[:len(query)-5]
+	var total int
+	err := s.db.QueryRow("SELECT count(*) FROM songs"+where, params...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	// Добавить пагинацию
-	query += fmt.Sprintf(" LIMIT 1")
+	query := fmt.Sprintf(`SELECT %s FROM songs%s
+	ORDER BY %s %s, id %s LIMIT $%d OFFSET $%d`,
+		songColumns, where, column, order, order, len(params)+1, len(params)+2)
+	params = append(params, opts.PageSize, (opts.Page-1)*opts.PageSize)
 
-	// Execute the query
 	rows, err := s.db.Query(query, params...)
-
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", op, err)
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
 	}
 	defer rows.Close()
 
-	// Scan the results and return them
-	var couplet string
+	songs := make([]Song, 0, opts.PageSize)
 	for rows.Next() {
-		err = rows.Scan(&couplet)
+		var song Song
+		err = rows.Scan(songFields(&song)...)
 		if err != nil {
-			return "", fmt.Errorf("%s: %w", op, err)
+			return nil, 0, fmt.Errorf("%s: %w", op, err)
 		}
+		songs = append(songs, song)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return songs, total, nil
+}
+
+// GetCouplet gets a page of verses of the lyrics of the first song matching
+// the filter together with the total number of verses
+func (s *Storage) GetCouplet(filter Song, page, size int) ([]string, int, error) {
+	const op = "storage.postgres.GetCouplet"
+
+	if page < 1 || size < 1 {
+		return nil, 0, fmt.Errorf("%s: %w", op, ErrInvalidPage)
+	}
+
+	where, params := buildWhere(filter)
+
+	var text string
+	err := s.db.QueryRow("SELECT text FROM songs"+where+" ORDER BY id LIMIT 1", params...).Scan(&text)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, 0, fmt.Errorf("%s: %w", op, ErrNotFound)
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	part := strings.Split(couplet, "\n")
-	fmt.Println(part[1])
-	fmt.Println(part[2])
+	verses, total, err := VersePage(text, page, size)
+	if err != nil {
+		return nil, total, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return verses, total, nil
+}
+
+// VersePage returns a page of verses of the lyrics together with the total
+// number of verses
+func VersePage(text string, page, size int) ([]string, int, error) {
+	if page < 1 || size < 1 {
+		return nil, 0, ErrInvalidPage
+	}
+
+	verses := lyrics.Verses(text)
+	start := (page - 1) * size
+	if start >= len(verses) {
+		return nil, len(verses), ErrPageOutOfRange
+	}
 
-	return part[page-1], nil
+	return verses[start:min(start+size, len(verses))], len(verses), nil
 }
-func (s *Storage) UpdateSong(song Song) error {
+
+// GetSongByID gets the song with given id
+func (s *Storage) GetSongByID(id string) (Song, error) {
+	const op = "storage.postgres.GetSongByID"
+
+	var song Song
+	if !uuidPattern.MatchString(id) {
+		return song, fmt.Errorf("%s: %w", op, ErrInvalidID)
+	}
+
+	err := s.db.QueryRow("SELECT "+songColumns+" FROM songs WHERE uuid = $1 AND deleted_at IS NULL", id).Scan(songFields(&song)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return song, fmt.Errorf("%s: %w", op, ErrNotFound)
+	}
+	if err != nil {
+		return song, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return song, nil
+}
+
+// Change describes who makes a mutation of a song and which version of the
+// song it expects
+type Change struct {
+	// Version is the required current version of the song, 0 matches any version
+	Version int
+	// Author is recorded in the song revision
+	Author string
+}
+
+// UpdateSong sets all the attributes of the song with the group and song of
+// the given one if its current version matches the change
+func (s *Storage) UpdateSong(song Song, change Change) (Song, error) {
 	const op = "storage.postgres.UpdateSong"
 
-	_, err := s.db.Exec(`
-		UPDATE songs
-		 SET "group" = $1, song = $2, release_date = $3, text = $4, link = $5
-		  WHERE "group" = $6 and song = $7;
-	`, song.Group,
-		song.Song,
-		song.ReleaseDate,
-		song.Text,
-		song.Link,
-		song.Group,
-		song.Song)
+	updated, err := s.patchSong(`"group" = $1 AND song = $2`, []interface{}{song.Group, song.Song},
+		fullPatch(song), change)
+	if err != nil {
+		return Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return updated, nil
+}
+
+// UpdateSongByID sets all the attributes of the song with given id if its
+// current version matches the change
+func (s *Storage) UpdateSongByID(id string, song Song, change Change) (Song, error) {
+	const op = "storage.postgres.UpdateSongByID"
+
+	if !uuidPattern.MatchString(id) {
+		return Song{}, fmt.Errorf("%s: %w", op, ErrInvalidID)
+	}
+
+	updated, err := s.patchSong("uuid = $1", []interface{}{id}, fullPatch(song), change)
+	if err != nil {
+		return Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return updated, nil
+}
+
+// SongPatch holds the song attributes to change, nil fields are left as is.
+// Sources are the enrichment providers of the patched attributes.
+type SongPatch struct {
+	Group       *string
+	Song        *string
+	ReleaseDate *string
+	Text        *string
+	Link        *string
+	Sources     Sources
+}
+
+func fullPatch(song Song) SongPatch {
+	return SongPatch{
+		Group:       &song.Group,
+		Song:        &song.Song,
+		ReleaseDate: &song.ReleaseDate,
+		Text:        &song.Text,
+		Link:        &song.Link,
+	}
+}
+
+// PatchSong changes the given attributes of the song with given id if its
+// current version matches the change
+func (s *Storage) PatchSong(id string, patch SongPatch, change Change) (Song, error) {
+	const op = "storage.postgres.PatchSong"
+
+	if !uuidPattern.MatchString(id) {
+		return Song{}, fmt.Errorf("%s: %w", op, ErrInvalidID)
+	}
+
+	song, err := s.patchSong("uuid = $1", []interface{}{id}, patch, change)
+	if err != nil {
+		return Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return song, nil
+}
+
+// PatchSongByName changes the given attributes of the song with given group
+// and song, which may be renamed by the patch
+func (s *Storage) PatchSongByName(group, name string, patch SongPatch, change Change) (Song, error) {
+	const op = "storage.postgres.PatchSongByName"
+
+	song, err := s.patchSong(`"group" = $1 AND song = $2`, []interface{}{group, name}, patch, change)
+	if err != nil {
+		return Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return song, nil
+}
+
+// patchSong applies the patch to the first song matched by where, bumps its
+// version and records the new revision unless the patch changes nothing
+func (s *Storage) patchSong(where string, params []interface{}, patch SongPatch, change Change) (Song, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Song{}, err
+	}
+	defer tx.Rollback()
+
+	song, err := s.patchSongTx(tx, where, params, patch, change)
+	if err != nil {
+		return Song{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Song{}, err
+	}
+
+	return song, nil
+}
+
+// patchSongTx applies the patch within the transaction
+func (s *Storage) patchSongTx(tx *sql.Tx, where string, params []interface{}, patch SongPatch, change Change) (Song, error) {
+	id, current, err := lockSong(tx, where, params, change.Version)
+	if err != nil {
+		return Song{}, err
+	}
+
+	// attributes set to their current values are left alone, a patch
+	// changing nothing makes no new version
+	params = []interface{}{id}
+	sets := make([]string, 0, 6)
+	for _, f := range []struct {
+		set     string
+		value   *string
+		current string
+	}{
+		{`"group" = $%d`, patch.Group, current.Group},
+		{"song = $%d", patch.Song, current.Song},
+		{"release_date = NULLIF($%d, '')::date", patch.ReleaseDate, current.ReleaseDate},
+		{"text = $%d", patch.Text, current.Text},
+		{"link = $%d", patch.Link, current.Link},
+	} {
+		if f.value == nil || *f.value == f.current {
+			continue
+		}
+		params = append(params, *f.value)
+		sets = append(sets, fmt.Sprintf(f.set, len(params)))
+	}
+	if len(sets) == 0 {
+		return current, nil
+	}
+	sets = append(sets, "version = version + 1")
+
+	var song Song
+	err = tx.QueryRow("UPDATE songs SET "+strings.Join(sets, ", ")+
+		" WHERE id = $1 RETURNING "+songColumns, params...).Scan(songFields(&song)...)
+	if err != nil {
+		return Song{}, err
+	}
+
+	if err := recordRevision(tx, song, OperationUpdate, change.Author); err != nil {
+		return Song{}, err
+	}
+
+	if err := recordSources(tx, song.ID, current, song, patch.Sources); err != nil {
+		return Song{}, err
+	}
+
+	if song.Text != current.Text {
+		if err := s.syncText(tx, &song, current.Text); err != nil {
+			return Song{}, err
+		}
+	}
+
+	return song, nil
+}
+
+// syncText updates the sections, language, explicitness, annotation anchors
+// and statistics of the song after its lyrics changed from the old ones
+func (s *Storage) syncText(tx *sql.Tx, song *Song, old string) error {
+	if _, err := saveSections(tx, song.ID, song.Text); err != nil {
+		return err
+	}
+	if err := tagLanguage(tx, song); err != nil {
+		return err
+	}
+	if err := s.tagExplicit(tx, song); err != nil {
+		return err
+	}
+	if err := reanchorAnnotations(tx, song.ID, old, song.Text); err != nil {
+		return err
+	}
+	return invalidateStats(tx, song.ID)
+}
+
+// DeleteSong moves the song with given group and song to the trash if its
+// current version matches the change
+func (s *Storage) DeleteSong(group, song string, change Change) error {
+	const op = "storage.postgres.DeleteSong"
+
+	err := s.deleteSong(`"group" = $1 AND song = $2`, []interface{}{group, song}, change)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 	return nil
 }
 
-func (s *Storage) DeleteSong(group, song string) error {
-	const op = "storage.postgres.DeleteSong"
+// DeleteSongByID moves the song with given id to the trash if its current
+// version matches the change
+func (s *Storage) DeleteSongByID(id string, change Change) error {
+	const op = "storage.postgres.DeleteSongByID"
+
+	if !uuidPattern.MatchString(id) {
+		return fmt.Errorf("%s: %w", op, ErrInvalidID)
+	}
 
-	_, err := s.db.Exec("DELETE FROM songs WHERE \"group\" = $1 and song = $2", group, song)
+	err := s.deleteSong("uuid = $1", []interface{}{id}, change)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 	return nil
 }
+
+// deleteSong moves the first song matched by where to the trash and records
+// its last state as the deletion revision
+func (s *Storage) deleteSong(where string, params []interface{}, change Change) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	id, song, err := lockSong(tx, where, params, change.Version)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow("UPDATE songs SET deleted_at = now(), version = version + 1 WHERE id = $1 RETURNING "+songColumns,
+		id).Scan(songFields(&song)...)
+	if err != nil {
+		return err
+	}
+
+	if err := recordRevision(tx, song, OperationDelete, change.Author); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// lockSong locks the first song matched by where that is not in the trash for
+// the rest of the transaction and checks its version, version 0 matches any
+// version
+func lockSong(tx *sql.Tx, where string, params []interface{}, version int) (int64, Song, error) {
+	var id int64
+	var song Song
+
+	err := tx.QueryRow("SELECT id, "+songColumns+" FROM songs WHERE ("+where+") AND deleted_at IS NULL ORDER BY id LIMIT 1 FOR UPDATE",
+		params...).Scan(append([]interface{}{&id}, songFields(&song)...)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, Song{}, ErrNotFound
+	}
+	if err != nil {
+		return 0, Song{}, err
+	}
+
+	if version != 0 && song.Version != version {
+		return 0, Song{}, ErrVersionConflict
+	}
+
+	return id, song, nil
+}
+
+// buildWhere builds the WHERE clause matching every non-empty field of the
+// filter among the songs that are not in the trash
+func buildWhere(filter Song) (string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	params := make([]interface{}, 0)
+
+	rv := reflect.ValueOf(filter)
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		value := rv.Field(i)
+
+		if value.Kind() != reflect.String || value.String() == "" {
+			continue
+		}
+		conditions = append(conditions,
+			fmt.Sprintf("\"%s\" = $%d", strings.ToLower(field.Tag.Get("db")), len(params)+1))
+		params = append(params, value.String())
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), params
+}
@@ -0,0 +1,200 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Operations recorded in song revisions
+const (
+	OperationCreate  = "create"
+	OperationUpdate  = "update"
+	OperationDelete  = "delete"
+	OperationRestore = "restore"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// SongSnapshot is the state of the versioned attributes of a song, the
+// detected language and explicitness are derived from the lyrics and are not
+// versioned
+type SongSnapshot struct {
+	ID          string `json:"id"`
+	Group       string `json:"group"`
+	Song        string `json:"song"`
+	ReleaseDate string `json:"release_date"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+	Version     int    `json:"version"`
+}
+
+// Revision is a state of a song after a mutation
+type Revision struct {
+	SongSnapshot
+	Operation string    `json:"operation"`
+	Author    string    `json:"author"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+const revisionColumns = `song_id, "group", song, release_date, text, link, version, operation, author, changed_at`
+
+// revisionFields returns the scan destinations of a revision for
+// revisionColumns
+func revisionFields(r *Revision) []interface{} {
+	return []interface{}{&r.ID, &r.Group, &r.Song, &r.ReleaseDate, &r.Text, &r.Link, &r.Version,
+		&r.Operation, &r.Author, &r.ChangedAt}
+}
+
+// recordRevision records the state of the song after a mutation
+func recordRevision(tx *sql.Tx, song Song, operation, author string) error {
+	_, err := tx.Exec(`INSERT INTO song_revisions
+	(song_id, version, operation, "group", song, release_date, text, link, author)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		song.ID, song.Version, operation, song.Group, song.Song, song.ReleaseDate, song.Text, song.Link, author)
+	return err
+}
+
+// ListRevisions gets all the revisions of the song with given id, latest first
+func (s *Storage) ListRevisions(id string) ([]Revision, error) {
+	const op = "storage.postgres.ListRevisions"
+
+	if !uuidPattern.MatchString(id) {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidID)
+	}
+
+	rows, err := s.db.Query("SELECT "+revisionColumns+" FROM song_revisions WHERE song_id = $1 ORDER BY version DESC", id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	revisions := make([]Revision, 0)
+	for rows.Next() {
+		var r Revision
+		if err := rows.Scan(revisionFields(&r)...); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		revisions = append(revisions, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(revisions) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, ErrNotFound)
+	}
+
+	return revisions, nil
+}
+
+// GetRevision gets the revision of the song with given id and version
+func (s *Storage) GetRevision(id string, version int) (Revision, error) {
+	const op = "storage.postgres.GetRevision"
+
+	if !uuidPattern.MatchString(id) {
+		return Revision{}, fmt.Errorf("%s: %w", op, ErrInvalidID)
+	}
+
+	r, err := getRevision(s.db, id, version)
+	if err != nil {
+		return Revision{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return r, nil
+}
+
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func getRevision(q queryRower, id string, version int) (Revision, error) {
+	var r Revision
+	err := q.QueryRow("SELECT "+revisionColumns+" FROM song_revisions WHERE song_id = $1 AND version = $2",
+		id, version).Scan(revisionFields(&r)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Revision{}, ErrRevisionNotFound
+	}
+	if err != nil {
+		return Revision{}, err
+	}
+
+	return r, nil
+}
+
+// RestoreRevision sets the attributes of the song with given id to the ones
+// of its revision with given version, bringing the song back from the trash
+// or recreating it when it was purged. The current version of an existing song must match the change.
+func (s *Storage) RestoreRevision(id string, version int, change Change) (Song, error) {
+	const op = "storage.postgres.RestoreRevision"
+
+	if !uuidPattern.MatchString(id) {
+		return Song{}, fmt.Errorf("%s: %w", op, ErrInvalidID)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	r, err := getRevision(tx, id, version)
+	if err != nil {
+		return Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var song Song
+	rowID, current, err := lockSong(tx, "uuid = $1", []interface{}{id}, change.Version)
+	switch {
+	case err == nil:
+		err = tx.QueryRow(`UPDATE songs
+		SET "group" = $2, song = $3, release_date = NULLIF($4, '')::date, text = $5, link = $6, version = version + 1
+		WHERE id = $1 RETURNING `+songColumns,
+			rowID, r.Group, r.Song, r.ReleaseDate, r.Text, r.Link).Scan(songFields(&song)...)
+	case errors.Is(err, ErrNotFound):
+		// annotations of a trashed song follow the restored lyrics too
+		err = tx.QueryRow("SELECT "+songColumns+" FROM songs WHERE uuid = $1 AND deleted_at IS NOT NULL FOR UPDATE",
+			id).Scan(songFields(&current)...)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			break
+		}
+		err = tx.QueryRow(`UPDATE songs
+		SET "group" = $2, song = $3, release_date = NULLIF($4, '')::date, text = $5, link = $6, version = version + 1,
+			deleted_at = NULL
+		WHERE uuid = $1 AND deleted_at IS NOT NULL RETURNING `+songColumns,
+			id, r.Group, r.Song, r.ReleaseDate, r.Text, r.Link).Scan(songFields(&song)...)
+		if !errors.Is(err, sql.ErrNoRows) {
+			break
+		}
+		// the song was purged from the trash
+		err = tx.QueryRow(`INSERT INTO songs (uuid, "group", song, release_date, text, link, version)
+		VALUES ($1, $2, $3, NULLIF($4, '')::date, $5, $6,
+			(SELECT max(version) + 1 FROM song_revisions WHERE song_id = $1))
+		RETURNING `+songColumns,
+			id, r.Group, r.Song, r.ReleaseDate, r.Text, r.Link).Scan(songFields(&song)...)
+	}
+	if err != nil {
+		return Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := recordRevision(tx, song, OperationRestore, change.Author); err != nil {
+		return Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if current.ID != "" {
+		if err := recordSources(tx, song.ID, current, song, nil); err != nil {
+			return Song{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := s.syncText(tx, &song, current.Text); err != nil {
+		return Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return song, nil
+}
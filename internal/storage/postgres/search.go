@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+)
+
+var ErrEmptyQuery = errors.New("empty search query")
+
+// SearchResult is a song found by full-text search with its rank and
+// a highlighted snippet of the lyrics
+type SearchResult struct {
+	Song
+	Rank float64 `json:"rank"`
+	// Snippet is HTML-escaped lyrics with the matches wrapped in <mark>
+	Snippet string `json:"snippet"`
+}
+
+// SearchOptions describes paging of a search
+type SearchOptions struct {
+	Page     int
+	PageSize int
+	// Clean leaves explicit songs out
+	Clean bool
+}
+
+// Private use characters ts_headline marks the matches with, they are
+// replaced by tags once the lyrics are escaped
+const (
+	markStart = "\ue000"
+	markStop  = "\ue001"
+)
+
+// headlineOptions configures ts_headline snippets of the lyrics
+const headlineOptions = "StartSel=" + markStart + ", StopSel=" + markStop +
+	", MaxFragments=2, MaxWords=20, MinWords=5, FragmentDelimiter= ... "
+
+var marks = strings.NewReplacer(markStart, "<mark>", markStop, "</mark>")
+
+// highlight escapes the headline of the lyrics for HTML and wraps its
+// matches in <mark> tags
+func highlight(headline string) string {
+	return marks.Replace(html.EscapeString(headline))
+}
+
+// SearchSongs gets a page of songs whose lyrics or titles match the query,
+// most relevant first, together with the total number of matches
+func (s *Storage) SearchSongs(query string, opts SearchOptions) ([]SearchResult, int, error) {
+	const op = "storage.postgres.SearchSongs"
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, 0, fmt.Errorf("%s: %w", op, ErrEmptyQuery)
+	}
+	if opts.Page < 1 || opts.PageSize < 1 {
+		return nil, 0, fmt.Errorf("%s: %w", op, ErrInvalidPage)
+	}
+
+	where := "search_vector @@ q AND deleted_at IS NULL"
+	if opts.Clean {
+		where += " AND NOT explicit"
+	}
+
+	var total int
+	err := s.db.QueryRow(`SELECT count(*) FROM songs, websearch_to_tsquery('simple', $1) q
+	WHERE `+where, query).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.db.Query(`SELECT `+songColumns+`,
+		ts_rank(search_vector, q) AS rank,
+		ts_headline('simple', text, q, $2)
+	FROM songs, websearch_to_tsquery('simple', $1) q
+	WHERE `+where+`
+	ORDER BY rank DESC, id
+	LIMIT $3 OFFSET $4`, query, headlineOptions, opts.PageSize, (opts.Page-1)*opts.PageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	results := make([]SearchResult, 0, opts.PageSize)
+	for rows.Next() {
+		var r SearchResult
+		err = rows.Scan(append(songFields(&r.Song), &r.Rank, &r.Snippet)...)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", op, err)
+		}
+		r.Snippet = highlight(r.Snippet)
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return results, total, nil
+}
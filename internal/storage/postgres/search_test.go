@@ -0,0 +1,27 @@
+package postgres
+
+import "testing"
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name     string
+		headline string
+		want     string
+	}{
+		{"plain", "no matches here", "no matches here"},
+		{"match", "the " + markStart + "night" + markStop + " is young", "the <mark>night</mark> is young"},
+		{
+			name:     "markup in the lyrics",
+			headline: `<script>alert("x")</script> ` + markStart + "rock" + markStop + " & roll",
+			want:     `&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; <mark>rock</mark> &amp; roll`,
+		},
+		{"quotes", "it's " + markStart + "\"here\"" + markStop, "it&#39;s <mark>&#34;here&#34;</mark>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlight(tt.headline); got != tt.want {
+				t.Errorf("highlight() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
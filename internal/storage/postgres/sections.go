@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/foreground-eclipse/song-library/internal/lib/lyrics"
+)
+
+// Lyrics is the structured lyrics of a song, Text is their rendered form
+type Lyrics struct {
+	ID       string           `json:"id"`
+	Group    string           `json:"group"`
+	Song     string           `json:"song"`
+	Sections []lyrics.Section `json:"sections"`
+	Text     string           `json:"text"`
+	// Annotations mark the annotated ranges of the lines of Text
+	Annotations []Marker `json:"annotations"`
+}
+
+// saveSections replaces the stored sections of the song with the ones
+// parsed from its lyrics
+func saveSections(tx *sql.Tx, songID, text string) ([]lyrics.Section, error) {
+	if _, err := tx.Exec("DELETE FROM song_sections WHERE song_id = $1", songID); err != nil {
+		return nil, err
+	}
+	return insertSections(tx, songID, text)
+}
+
+// insertSections stores the sections parsed from the lyrics of the song,
+// keeping the sections stored meanwhile by a concurrent backfill
+func insertSections(tx *sql.Tx, songID, text string) ([]lyrics.Section, error) {
+	sections := lyrics.Sections(text)
+	for _, s := range sections {
+		_, err := tx.Exec(`INSERT INTO song_sections (song_id, position, type, label, text, repeat_of)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (song_id, position) DO NOTHING`,
+			songID, s.Position, s.Type, s.Label, s.Text, s.RepeatOf)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sections, nil
+}
+
+// GetLyrics gets the structured lyrics of the song with given id, parsing
+// and storing the sections of songs added before they were kept
+func (s *Storage) GetLyrics(id string) (Lyrics, error) {
+	const op = "storage.postgres.GetLyrics"
+
+	song, err := s.GetSongByID(id)
+	if err != nil {
+		return Lyrics{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	l := Lyrics{
+		ID:    song.ID,
+		Group: song.Group,
+		Song:  song.Song,
+		Text:  song.Text,
+	}
+
+	rows, err := s.db.Query(`SELECT position, type, label, text, repeat_of FROM song_sections
+	WHERE song_id = $1 ORDER BY position`, id)
+	if err != nil {
+		return Lyrics{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	l.Sections = make([]lyrics.Section, 0)
+	for rows.Next() {
+		var section lyrics.Section
+		var repeatOf sql.NullInt64
+		err := rows.Scan(&section.Position, &section.Type, &section.Label, &section.Text, &repeatOf)
+		if err != nil {
+			return Lyrics{}, fmt.Errorf("%s: %w", op, err)
+		}
+		if repeatOf.Valid {
+			position := int(repeatOf.Int64)
+			section.RepeatOf = &position
+		}
+		l.Sections = append(l.Sections, section)
+	}
+	if err := rows.Err(); err != nil {
+		return Lyrics{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	l.Annotations, err = s.annotationMarkers(id)
+	if err != nil {
+		return Lyrics{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(l.Sections) > 0 || song.Text == "" {
+		return l, nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Lyrics{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	l.Sections, err = insertSections(tx, song.ID, song.Text)
+	if err != nil {
+		return Lyrics{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Lyrics{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return l, nil
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"database/sql"
+)
+
+// Sources maps the attributes of a song to the enrichment providers they
+// were taken from
+type Sources map[string]string
+
+// recordSources records the providers of the attributes of the song given
+// by the sources, the attributes changed otherwise lose their provider
+func recordSources(tx *sql.Tx, songID string, old, song Song, sources Sources) error {
+	for _, f := range []struct {
+		name     string
+		old, new string
+	}{
+		{"release_date", old.ReleaseDate, song.ReleaseDate},
+		{"text", old.Text, song.Text},
+		{"link", old.Link, song.Link},
+	} {
+		switch provider := sources[f.name]; {
+		case provider != "" && f.new != "":
+			_, err := tx.Exec(`INSERT INTO song_info_sources (song_id, field, provider) VALUES ($1, $2, $3)
+			ON CONFLICT (song_id, field) DO UPDATE SET provider = excluded.provider, recorded_at = now()`,
+				songID, f.name, provider)
+			if err != nil {
+				return err
+			}
+		case f.old != f.new:
+			_, err := tx.Exec("DELETE FROM song_info_sources WHERE song_id = $1 AND field = $2", songID, f.name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// getSources gets the providers of the attributes of the song
+func (s *Storage) getSources(songID string) (Sources, error) {
+	rows, err := s.db.Query("SELECT field, provider FROM song_info_sources WHERE song_id = $1", songID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sources := make(Sources)
+	for rows.Next() {
+		var field, provider string
+		if err := rows.Scan(&field, &provider); err != nil {
+			return nil, err
+		}
+		sources[field] = provider
+	}
+
+	return sources, rows.Err()
+}
@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/foreground-eclipse/song-library/internal/lib/analysis"
+)
+
+// MaxTopWords is the largest number of most frequent words in statistics
+const MaxTopWords = 50
+
+// SongStats are the statistics of the lyrics of a song
+type SongStats struct {
+	ID       string `json:"id"`
+	Group    string `json:"group"`
+	Song     string `json:"song"`
+	Language string `json:"language"`
+	analysis.Stats
+}
+
+// GroupStats are the statistics of the lyrics of all the songs of a group
+type GroupStats struct {
+	Group string `json:"group"`
+	Songs int    `json:"songs"`
+	analysis.Stats
+}
+
+// GetSongStats gets the statistics of the lyrics of the song with given id
+// with up to top most frequent words. The statistics are computed once per
+// version of the song and cached until its lyrics change.
+func (s *Storage) GetSongStats(id string, top int) (SongStats, error) {
+	const op = "storage.postgres.GetSongStats"
+
+	if top < 0 || top > MaxTopWords {
+		return SongStats{}, fmt.Errorf("%s: %w", op, ErrInvalidPage)
+	}
+
+	song, err := s.GetSongByID(id)
+	if err != nil {
+		return SongStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stats := SongStats{
+		ID:       song.ID,
+		Group:    song.Group,
+		Song:     song.Song,
+		Language: song.Language,
+	}
+
+	var cached []byte
+	err = s.db.QueryRow("SELECT stats FROM song_stats WHERE song_id = $1 AND version = $2",
+		id, song.Version).Scan(&cached)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(cached, &stats.Stats); err != nil {
+			return SongStats{}, fmt.Errorf("%s: %w", op, err)
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		stats.Stats = analysis.Analyze(song.Text, analysis.StopWordsFor(song.Language), MaxTopWords)
+		computed, err := json.Marshal(stats.Stats)
+		if err != nil {
+			return SongStats{}, fmt.Errorf("%s: %w", op, err)
+		}
+		// a concurrent update of the song leaves the newer statistics in place
+		_, err = s.db.Exec(`INSERT INTO song_stats (song_id, version, stats) VALUES ($1, $2, $3)
+		ON CONFLICT (song_id) DO UPDATE SET version = EXCLUDED.version, stats = EXCLUDED.stats, computed_at = now()
+		WHERE song_stats.version < EXCLUDED.version`, id, song.Version, computed)
+		if err != nil {
+			return SongStats{}, fmt.Errorf("%s: %w", op, err)
+		}
+	default:
+		return SongStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stats.TopWords = stats.TopWords[:min(top, len(stats.TopWords))]
+	return stats, nil
+}
+
+// GetGroupStats gets the statistics of the lyrics of all the songs of the
+// group taken together with up to top most frequent words. The statistics
+// are computed once per set of versions of the songs of the group and cached
+// until a song of the group is added, removed or changed.
+func (s *Storage) GetGroupStats(group string, top int) (GroupStats, error) {
+	const op = "storage.postgres.GetGroupStats"
+
+	if top < 0 || top > MaxTopWords {
+		return GroupStats{}, fmt.Errorf("%s: %w", op, ErrInvalidPage)
+	}
+
+	// the versions of the songs of the group identify the cached statistics
+	var songs int
+	var version string
+	err := s.db.QueryRow(`SELECT count(*), coalesce(md5(string_agg(uuid || ':' || version, ',' ORDER BY id)), '')
+	FROM songs WHERE "group" = $1 AND deleted_at IS NULL`, group).Scan(&songs, &version)
+	if err != nil {
+		return GroupStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if songs == 0 {
+		return GroupStats{}, fmt.Errorf("%s: %w", op, ErrNotFound)
+	}
+
+	stats := GroupStats{Group: group, Songs: songs}
+
+	var cached []byte
+	err = s.db.QueryRow(`SELECT stats FROM group_stats WHERE "group" = $1 AND songs_version = $2`,
+		group, version).Scan(&cached)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(cached, &stats.Stats); err != nil {
+			return GroupStats{}, fmt.Errorf("%s: %w", op, err)
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		stats.Stats, err = s.analyzeGroup(group)
+		if err != nil {
+			return GroupStats{}, fmt.Errorf("%s: %w", op, err)
+		}
+		computed, err := json.Marshal(stats.Stats)
+		if err != nil {
+			return GroupStats{}, fmt.Errorf("%s: %w", op, err)
+		}
+		_, err = s.db.Exec(`INSERT INTO group_stats ("group", songs_version, songs, stats) VALUES ($1, $2, $3, $4)
+		ON CONFLICT ("group") DO UPDATE
+		SET songs_version = EXCLUDED.songs_version, songs = EXCLUDED.songs, stats = EXCLUDED.stats, computed_at = now()`,
+			group, version, songs, computed)
+		if err != nil {
+			return GroupStats{}, fmt.Errorf("%s: %w", op, err)
+		}
+	default:
+		return GroupStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stats.TopWords = stats.TopWords[:min(top, len(stats.TopWords))]
+	return stats, nil
+}
+
+// analyzeGroup computes the statistics of the lyrics of all the songs of the
+// group with MaxTopWords most frequent words
+func (s *Storage) analyzeGroup(group string) (analysis.Stats, error) {
+	rows, err := s.db.Query(`SELECT text, coalesce(language, '') FROM songs
+	WHERE "group" = $1 AND deleted_at IS NULL ORDER BY id`, group)
+	if err != nil {
+		return analysis.Stats{}, err
+	}
+	defer rows.Close()
+
+	texts := make([]string, 0)
+	langs := make([]string, 0)
+	for rows.Next() {
+		var text, lang string
+		if err := rows.Scan(&text, &lang); err != nil {
+			return analysis.Stats{}, err
+		}
+		texts = append(texts, text)
+		langs = append(langs, lang)
+	}
+	if err := rows.Err(); err != nil {
+		return analysis.Stats{}, err
+	}
+
+	return analysis.AnalyzeAll(texts, analysis.StopWordsFor(langs...), MaxTopWords), nil
+}
+
+// invalidateStats drops the cached statistics of the song
+func invalidateStats(tx *sql.Tx, songID string) error {
+	_, err := tx.Exec("DELETE FROM song_stats WHERE song_id = $1", songID)
+	return err
+}
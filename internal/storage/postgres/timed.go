@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/foreground-eclipse/song-library/internal/lib/lrc"
+)
+
+var ErrNoTimedLines = errors.New("song has no synced lyrics")
+
+// SaveTimedLines replaces the synced lyrics of the song with given id
+func (s *Storage) SaveTimedLines(id string, lines []lrc.Line) error {
+	const op = "storage.postgres.SaveTimedLines"
+
+	if !uuidPattern.MatchString(id) {
+		return fmt.Errorf("%s: %w", op, ErrInvalidID)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, _, err := lockSong(tx, "uuid = $1", []interface{}{id}, 0); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM song_timed_lines WHERE song_id = $1", id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i, l := range lines {
+		_, err := tx.Exec(`INSERT INTO song_timed_lines (song_id, position, start_ms, text)
+		VALUES ($1, $2, $3, $4)`, id, i+1, l.Start.Milliseconds(), l.Text)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetTimedLines gets the song with given id and its synced lyrics ordered
+// by start time
+func (s *Storage) GetTimedLines(id string) (Song, []lrc.Line, error) {
+	const op = "storage.postgres.GetTimedLines"
+
+	song, err := s.GetSongByID(id)
+	if err != nil {
+		return Song{}, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.db.Query(`SELECT start_ms, text FROM song_timed_lines
+	WHERE song_id = $1 ORDER BY start_ms, position`, id)
+	if err != nil {
+		return Song{}, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	lines := make([]lrc.Line, 0)
+	for rows.Next() {
+		var l lrc.Line
+		var ms int64
+		if err := rows.Scan(&ms, &l.Text); err != nil {
+			return Song{}, nil, fmt.Errorf("%s: %w", op, err)
+		}
+		l.Start = time.Duration(ms) * time.Millisecond
+		lines = append(lines, l)
+	}
+	if err := rows.Err(); err != nil {
+		return Song{}, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(lines) == 0 {
+		return Song{}, nil, fmt.Errorf("%s: %w", op, ErrNoTimedLines)
+	}
+
+	return song, lines, nil
+}
+
+// DeleteTimedLines deletes the synced lyrics of the song with given id
+func (s *Storage) DeleteTimedLines(id string) error {
+	const op = "storage.postgres.DeleteTimedLines"
+
+	if !uuidPattern.MatchString(id) {
+		return fmt.Errorf("%s: %w", op, ErrInvalidID)
+	}
+
+	res, err := s.db.Exec("DELETE FROM song_timed_lines WHERE song_id = $1", id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, ErrNoTimedLines)
+	}
+
+	return nil
+}
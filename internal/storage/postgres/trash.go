@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TrashedSong is a deleted song waiting in the trash to be purged
+type TrashedSong struct {
+	Song
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// ListTrash gets a page of deleted songs, most recently deleted first,
+// together with the total number of songs in the trash
+func (s *Storage) ListTrash(page, pageSize int) ([]TrashedSong, int, error) {
+	const op = "storage.postgres.ListTrash"
+
+	if page < 1 || pageSize < 1 {
+		return nil, 0, fmt.Errorf("%s: %w", op, ErrInvalidPage)
+	}
+
+	var total int
+	err := s.db.QueryRow("SELECT count(*) FROM songs WHERE deleted_at IS NOT NULL").Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.db.Query(`SELECT `+songColumns+`, deleted_at FROM songs
+	WHERE deleted_at IS NOT NULL
+	ORDER BY deleted_at DESC, id LIMIT $1 OFFSET $2`, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	songs := make([]TrashedSong, 0, pageSize)
+	for rows.Next() {
+		var song TrashedSong
+		if err := rows.Scan(append(songFields(&song.Song), &song.DeletedAt)...); err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", op, err)
+		}
+		songs = append(songs, song)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return songs, total, nil
+}
+
+// RestoreSong brings the song with given id back from the trash if its
+// current version matches the change
+func (s *Storage) RestoreSong(id string, change Change) (Song, error) {
+	const op = "storage.postgres.RestoreSong"
+
+	if !uuidPattern.MatchString(id) {
+		return Song{}, fmt.Errorf("%s: %w", op, ErrInvalidID)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var song Song
+	err = tx.QueryRow(`UPDATE songs SET deleted_at = NULL, version = version + 1
+	WHERE uuid = $1 AND deleted_at IS NOT NULL AND ($2 = 0 OR version = $2)
+	RETURNING `+songColumns, id, change.Version).Scan(songFields(&song)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = ErrNotFound
+		if change.Version != 0 {
+			var exists bool
+			if qerr := tx.QueryRow("SELECT true FROM songs WHERE uuid = $1 AND deleted_at IS NOT NULL",
+				id).Scan(&exists); qerr == nil {
+				err = ErrVersionConflict
+			}
+		}
+	}
+	if err != nil {
+		return Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := recordRevision(tx, song, OperationRestore, change.Author); err != nil {
+		return Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return song, nil
+}
+
+// PurgeTrash permanently deletes the songs that have been in the trash for
+// longer than the retention and returns the number of purged songs
+func (s *Storage) PurgeTrash(retention time.Duration) (int64, error) {
+	const op = "storage.postgres.PurgeTrash"
+
+	res, err := s.db.Exec("DELETE FROM songs WHERE deleted_at < $1", time.Now().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
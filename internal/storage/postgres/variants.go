@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Kinds of lyrics variants
+const (
+	KindTranslation     = "translation"
+	KindTransliteration = "transliteration"
+)
+
+var (
+	ErrVariantNotFound = errors.New("lyrics variant not found")
+	ErrInvalidKind     = errors.New("kind must be translation or transliteration")
+)
+
+// Variant is a translation or transliteration of the lyrics of a song in
+// the language given by a BCP 47 tag
+type Variant struct {
+	SongID    string    `json:"song_id"`
+	Lang      string    `json:"lang"`
+	Kind      string    `json:"kind"`
+	Text      string    `json:"text"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ValidKind reports whether the kind is a kind of lyrics variants
+func ValidKind(kind string) bool {
+	return kind == KindTranslation || kind == KindTransliteration
+}
+
+// SaveVariant adds or replaces the lyrics variant of the song
+func (s *Storage) SaveVariant(v Variant) (Variant, error) {
+	const op = "storage.postgres.SaveVariant"
+
+	if !uuidPattern.MatchString(v.SongID) {
+		return Variant{}, fmt.Errorf("%s: %w", op, ErrInvalidID)
+	}
+	if !ValidKind(v.Kind) {
+		return Variant{}, fmt.Errorf("%s: %w", op, ErrInvalidKind)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Variant{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, _, err := lockSong(tx, "uuid = $1", []interface{}{v.SongID}, 0); err != nil {
+		return Variant{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = tx.QueryRow(`INSERT INTO song_variants (song_id, lang, kind, text)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (song_id, lang, kind) DO UPDATE SET text = EXCLUDED.text, updated_at = now()
+	RETURNING updated_at`, v.SongID, v.Lang, v.Kind, v.Text).Scan(&v.UpdatedAt)
+	if err != nil {
+		return Variant{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Variant{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return v, nil
+}
+
+// ListVariants gets all the lyrics variants of the song with given id
+func (s *Storage) ListVariants(id string) ([]Variant, error) {
+	const op = "storage.postgres.ListVariants"
+
+	if !uuidPattern.MatchString(id) {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidID)
+	}
+
+	rows, err := s.db.Query(`SELECT song_id, lang, kind, text, updated_at FROM song_variants
+	WHERE song_id = $1 ORDER BY lang, kind`, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	variants := make([]Variant, 0)
+	for rows.Next() {
+		var v Variant
+		if err := rows.Scan(&v.SongID, &v.Lang, &v.Kind, &v.Text, &v.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		variants = append(variants, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return variants, nil
+}
+
+// GetVariant gets the lyrics variant of the song with given id, language
+// and kind
+func (s *Storage) GetVariant(id, lang, kind string) (Variant, error) {
+	const op = "storage.postgres.GetVariant"
+
+	if !uuidPattern.MatchString(id) {
+		return Variant{}, fmt.Errorf("%s: %w", op, ErrInvalidID)
+	}
+
+	v := Variant{SongID: id, Lang: lang, Kind: kind}
+	err := s.db.QueryRow(`SELECT text, updated_at FROM song_variants
+	WHERE song_id = $1 AND lang = $2 AND kind = $3`, id, lang, kind).Scan(&v.Text, &v.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Variant{}, fmt.Errorf("%s: %w", op, ErrVariantNotFound)
+	}
+	if err != nil {
+		return Variant{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return v, nil
+}
+
+// DeleteVariant deletes the lyrics variant of the song with given id,
+// language and kind
+func (s *Storage) DeleteVariant(id, lang, kind string) error {
+	const op = "storage.postgres.DeleteVariant"
+
+	if !uuidPattern.MatchString(id) {
+		return fmt.Errorf("%s: %w", op, ErrInvalidID)
+	}
+
+	res, err := s.db.Exec("DELETE FROM song_variants WHERE song_id = $1 AND lang = $2 AND kind = $3", id, lang, kind)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, ErrVariantNotFound)
+	}
+
+	return nil
+}